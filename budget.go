@@ -0,0 +1,111 @@
+package errgroup
+
+import (
+	"context"
+	"sync"
+)
+
+// SetMemoryBudget sets the total estimated memory, in bytes, which the
+// routines of members added with AddWithMemory may use at once, e.g. to
+// avoid running out of memory when fanning out memory-heavy members.
+//
+// When the Group runs, the routine of a member with a memory cost waits
+// until its cost fits within the budget alongside the routines which are
+// already running, and releases its cost once it returns. A member which is
+// waiting for its cost to fit does not count as started for WaitStarted.
+// This is a soft admission control: the costs are estimates declared by the
+// members, not measurements. A member which is still waiting when the Group
+// terminates is not run, but its terminate function is still called. A
+// budget of zero or less disables the limit, which is the default.
+func (g *Group) SetMemoryBudget(bytes int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.memory = bytes
+}
+
+// AddWithMemory adds a new member to the Group whose routine is estimated to
+// use the given number of bytes of memory while it runs. The cost counts
+// against the budget set by SetMemoryBudget, and must not exceed it.
+//
+// It behaves the same as Add, otherwise.
+func (g *Group) AddWithMemory(cost int64, routine func() error, terminate func(error)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.members = append(g.members, &member{routine: routine, terminate: terminate, cost: cost})
+}
+
+// budget is a weighted semaphore which limits the total cost of the member
+// routines running at once. A nil budget has no limit.
+type budget struct {
+	mu   sync.Mutex
+	size int64
+	used int64
+
+	// released is closed and replaced whenever cost is released.
+	released chan struct{}
+
+	// closed is set once the group terminates, after which no more
+	// routines are admitted.
+	closed bool
+}
+
+// newBudget returns a budget of the given size, or nil if size is zero or
+// less.
+func newBudget(size int64) *budget {
+	if size <= 0 {
+		return nil
+	}
+	return &budget{size: size, released: make(chan struct{})}
+}
+
+// acquire waits until n fits within the budget and reserves it, returning
+// false if ctx is done or the budget is closed first.
+func (b *budget) acquire(ctx context.Context, n int64) bool {
+	if b == nil || n <= 0 {
+		return true
+	}
+	for {
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			return false
+		}
+		if b.used+n <= b.size {
+			b.used += n
+			b.mu.Unlock()
+			return true
+		}
+		released := b.released
+		b.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// release n reserved by acquire.
+func (b *budget) release(n int64) {
+	if b == nil || n <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used -= n
+	close(b.released)
+	b.released = make(chan struct{})
+}
+
+// close the budget, so routines which are still waiting are not admitted.
+func (b *budget) close() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	close(b.released)
+	b.released = make(chan struct{})
+}
@@ -0,0 +1,161 @@
+package errgroup
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroup_SetMemoryBudget(t *testing.T) {
+	var running, peak int32
+
+	var g Group
+	g.SetMemoryBudget(10)
+	for i := 0; i < 3; i++ {
+		g.AddWithMemory(
+			6,
+			func() error {
+				n := atomic.AddInt32(&running, 1)
+				defer atomic.AddInt32(&running, -1)
+				for {
+					p := atomic.LoadInt32(&peak)
+					if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				return nil
+			},
+			func(e error) {},
+		)
+	}
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if p := atomic.LoadInt32(&peak); p != 1 {
+			t.Errorf("%d routines ran at once, expected 1", p)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_SetMemoryBudgetTerminated(t *testing.T) {
+	var started int32
+
+	var g Group
+	g.SetMemoryBudget(10)
+	for i := 0; i < 2; i++ {
+		cancel := make(chan struct{})
+		g.AddWithMemory(
+			10,
+			func() error {
+				atomic.AddInt32(&started, 1)
+				<-cancel
+				return nil
+			},
+			func(e error) {
+				close(cancel)
+			},
+		)
+	}
+	g.Add(
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	select {
+	case err := <-res:
+		if err != errTest {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if n := atomic.LoadInt32(&started); n > 1 {
+			t.Errorf("%d routines started, expected at most 1", n)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_SetMemoryBudgetWaitStarted(t *testing.T) {
+	release := make(chan struct{})
+
+	var g Group
+	g.SetMemoryBudget(10)
+	for i := 0; i < 2; i++ {
+		g.AddWithMemory(
+			10,
+			func() error {
+				<-release
+				return nil
+			},
+			func(e error) {},
+		)
+	}
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	// The second member is not started until the first releases its cost.
+	started := make(chan struct{})
+	go func() {
+		g.WaitStarted()
+		close(started)
+	}()
+	select {
+	case <-started:
+		t.Fatal("started before all members were admitted")
+	case <-time.After(10 * time.Millisecond):
+	}
+	release <- struct{}{}
+
+	select {
+	case <-started:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("test case timeout")
+	}
+	release <- struct{}{}
+
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_ValidateMemoryCost(t *testing.T) {
+	var g Group
+	g.SetMemoryBudget(10)
+	g.AddWithMemory(
+		11,
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+
+	if err := g.Validate(); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("got unexpected error: %v", err)
+	}
+}
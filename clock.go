@@ -0,0 +1,81 @@
+package errgroup
+
+import (
+	"time"
+)
+
+// Clock provides the current time and timers to a Group, so that its
+// time-based behavior can be driven by a fake clock in tests.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After returns a channel on which the current time is sent once d
+	// has elapsed.
+	After(d time.Duration) <-chan time.Time
+
+	// NewTimer returns a Timer which fires once d has elapsed.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a single event created by a Clock.
+type Timer interface {
+	// C returns the channel on which the time is sent when the Timer
+	// fires.
+	C() <-chan time.Time
+
+	// Stop prevents the Timer from firing, returning false if it has
+	// already fired or been stopped.
+	Stop() bool
+}
+
+// SetClock sets the Clock used by the Group for member timeouts, health
+// checks, stuck terminate detection, scheduled members, retry backoffs, the
+// idle timeout and the durations in its Report. By default the real time is
+// used.
+func (g *Group) SetClock(c Clock) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.clk = c
+}
+
+// clock returns the Clock of the Group, or the real time if none is set.
+func (o *options) clock() Clock {
+	if o.clk != nil {
+		return o.clk
+	}
+	return realClock{}
+}
+
+// realClock is a Clock using the real time.
+type realClock struct{}
+
+// Now returns the current time.
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// After returns a channel on which the current time is sent after d.
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// NewTimer returns a Timer which fires after d.
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+// realTimer is a Timer using the real time.
+type realTimer struct {
+	t *time.Timer
+}
+
+// C returns the channel on which the time is sent when the Timer fires.
+func (t realTimer) C() <-chan time.Time {
+	return t.t.C
+}
+
+// Stop prevents the Timer from firing.
+func (t realTimer) Stop() bool {
+	return t.t.Stop()
+}
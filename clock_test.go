@@ -0,0 +1,292 @@
+package errgroup
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock whose time only moves when advanced.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+
+	// created receives a value whenever a timer is created.
+	created chan struct{}
+}
+
+type fakeTimer struct {
+	clk  *fakeClock
+	at   time.Time
+	c    chan time.Time
+	done bool
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(0, 0), created: make(chan struct{}, 100)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{clk: c, at: c.now.Add(d), c: make(chan time.Time, 1)}
+	c.timers = append(c.timers, t)
+	c.created <- struct{}{}
+	return t
+}
+
+// Advance the clock by d, firing any timers which are due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for _, t := range c.timers {
+		if !t.done && !t.at.After(c.now) {
+			t.done = true
+			t.c <- c.now
+		}
+	}
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clk.mu.Lock()
+	defer t.clk.mu.Unlock()
+	stopped := !t.done
+	t.done = true
+	return stopped
+}
+
+func TestGroup_SetClock(t *testing.T) {
+	clk := newFakeClock()
+	cancel := make(chan struct{})
+	var once sync.Once
+
+	var g Group
+	g.SetClock(clk)
+	g.AddWithTimeout(
+		func() error {
+			<-cancel
+			return nil
+		},
+		func(e error) {
+			once.Do(func() {
+				close(cancel)
+			})
+		},
+		time.Hour,
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	<-clk.created
+	clk.Advance(time.Minute)
+	select {
+	case err := <-res:
+		t.Fatalf("run returned before the timeout: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	clk.Advance(time.Hour)
+	select {
+	case err := <-res:
+		if !errors.Is(err, ErrMemberTimeout) {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if d := g.Report().Duration; d != time.Hour+time.Minute {
+			t.Errorf("unexpected duration: %v", d)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_AddWithAbsoluteDeadline(t *testing.T) {
+	clk := newFakeClock()
+	cancel := make(chan struct{})
+	var once sync.Once
+
+	at := clk.Now().Add(time.Hour)
+
+	var g Group
+	g.SetClock(clk)
+	g.AddWithAbsoluteDeadline(
+		"test",
+		at,
+		func() error {
+			<-cancel
+			return nil
+		},
+		func(e error) {
+			once.Do(func() {
+				close(cancel)
+			})
+		},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	<-clk.created
+	clk.Advance(time.Hour)
+
+	select {
+	case err := <-res:
+		if !errors.Is(err, ErrMemberDeadline) {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if want := "errgroup: member missed its deadline: test at " + at.Format(time.RFC3339Nano); err.Error() != want {
+			t.Errorf("unexpected error message: %q", err)
+		}
+		if r := g.StopReason(); r != ReasonTimeout {
+			t.Errorf("unexpected stop reason: %v", r)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_RunWithRetryAfter(t *testing.T) {
+	clk := newFakeClock()
+
+	var g Group
+	g.SetClock(clk)
+	var calls int
+	g.Add(
+		func() error {
+			calls++
+			if calls == 1 {
+				return RetryAfter(time.Hour)
+			}
+			return nil
+		},
+		func(e error) {},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.RunWithRetry(2, func(attempt int) time.Duration {
+			t.Error("backoff called, but not expected")
+			return 0
+		})
+	}()
+
+	<-clk.created
+	clk.Advance(time.Minute)
+	select {
+	case err := <-res:
+		t.Fatalf("retried before the delay: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	clk.Advance(time.Hour)
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if calls != 2 {
+			t.Errorf("routine called %d times, expected 2", calls)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_RunWithRetryStop(t *testing.T) {
+	clk := newFakeClock()
+
+	var g Group
+	g.SetClock(clk)
+	var calls int
+	g.Add(
+		func() error {
+			calls++
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.RunWithRetry(5, func(attempt int) time.Duration {
+			return time.Hour
+		})
+	}()
+
+	// Stop aborts the wait between runs.
+	<-clk.created
+	g.Stop()
+	select {
+	case err := <-res:
+		if err != errTest {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("routine called %d times, expected 1", calls)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_RunWithRetryStopConcurrent(t *testing.T) {
+	clk := newFakeClock()
+
+	var g Group
+	g.SetClock(clk)
+	release := make(chan struct{})
+	g.Add(
+		func() error {
+			<-release
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.RunWithRetry(5, func(attempt int) time.Duration {
+			return time.Hour
+		})
+	}()
+
+	// A call which cannot run the Group does not prevent Stop from
+	// aborting the call which is running it.
+	g.WaitStarted()
+	if err := g.RunWithRetry(5, nil); err != ErrRunning {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	close(release)
+
+	<-clk.created
+	g.Stop()
+	select {
+	case err := <-res:
+		if err != errTest {
+			t.Errorf("got unexpected error: %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
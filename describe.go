@@ -0,0 +1,233 @@
+package errgroup
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Settings holds the values of the options configured on a Group.
+type Settings struct {
+	// StopAfterN is the number of routines which must return nil for the
+	// Group to terminate cleanly, as set by StopAfterN.
+	StopAfterN int `json:"stop_after_n,omitempty"`
+
+	// StopOnFirstNil is whether the first routine to return nil terminates
+	// the Group, as set by StopOnFirstNil.
+	StopOnFirstNil bool `json:"stop_on_first_nil"`
+
+	// StartBarrier is whether member routines start behind a barrier, as
+	// set by StartBarrier.
+	StartBarrier bool `json:"start_barrier"`
+
+	// RecoverPanics is whether panics in member routines are recovered, as
+	// set by RecoverPanics.
+	RecoverPanics bool `json:"recover_panics"`
+
+	// ReportCompletion is whether a clean completion is reported with an
+	// error, as set by ReportCompletion.
+	ReportCompletion bool `json:"report_completion"`
+
+	// SkipNilRoutines is whether members without a routine are skipped, as
+	// set by SkipNilRoutines.
+	SkipNilRoutines bool `json:"skip_nil_routines"`
+
+	// NoTerminateOnCleanCompletion is whether terminate functions are
+	// skipped on a clean completion, as set by NoTerminateOnCleanCompletion.
+	NoTerminateOnCleanCompletion bool `json:"no_terminate_on_clean_completion"`
+
+	// IdempotentTerminate is whether terminate functions are called at
+	// most once per run, as set by IdempotentTerminate.
+	IdempotentTerminate bool `json:"idempotent_terminate"`
+
+	// DebugDetectStuckTerminate is how long a terminated routine may take
+	// to return before it is reported as stuck, as set by
+	// DebugDetectStuckTerminate.
+	DebugDetectStuckTerminate time.Duration `json:"debug_detect_stuck_terminate,omitempty"`
+
+	// AbortGrace is how long the routine of a member terminated early is
+	// waited for, as set by SetAbortGrace.
+	AbortGrace time.Duration `json:"abort_grace,omitempty"`
+
+	// DedupeErrors is whether duplicate errors are collapsed before they
+	// are combined, as set by DedupeErrors.
+	DedupeErrors bool `json:"dedupe_errors"`
+
+	// AggregateWhenMultiple is whether several errors are returned as a
+	// MultiError, as set by AggregateWhenMultiple.
+	AggregateWhenMultiple bool `json:"aggregate_when_multiple"`
+
+	// ReturnOnError is whether Run returns as soon as the error which
+	// triggers termination is known, as set by ReturnOnError.
+	ReturnOnError bool `json:"return_on_error"`
+
+	// IdleTimeout is how long members may go without reporting activity
+	// before the Group terminates, as set by IdleTimeout.
+	IdleTimeout time.Duration `json:"idle_timeout,omitempty"`
+
+	// MemoryBudget is the total estimated memory member routines may use at
+	// once, as set by SetMemoryBudget.
+	MemoryBudget int64 `json:"memory_budget,omitempty"`
+
+	// Attribution is whether member errors are wrapped in a MemberError,
+	// as set by SetAttribution.
+	Attribution bool `json:"attribution"`
+
+	// MaxRecordedErrors is the maximum number of member errors recorded
+	// during a run, as set by MaxRecordedErrors.
+	MaxRecordedErrors int `json:"max_recorded_errors,omitempty"`
+
+	// SeverityFloor is the severity below which member errors do not
+	// terminate the Group, as set by SetSeverityFloor. It only applies if
+	// HasSeverityFloor is set.
+	SeverityFloor int `json:"severity_floor,omitempty"`
+
+	// HasSeverityFloor is whether a severity floor is set.
+	HasSeverityFloor bool `json:"has_severity_floor"`
+}
+
+// Settings returns the values of the options configured on the Group, e.g.
+// for a startup self-check to log the effective configuration.
+func (g *Group) Settings() Settings {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.settings()
+}
+
+// settings returns the values of the options configured on the Group.
+// g.mu must be held.
+func (g *Group) settings() Settings {
+	return Settings{
+		StopAfterN:       g.quorum,
+		StopOnFirstNil:   g.firstNil,
+		StartBarrier:     g.barrier,
+		RecoverPanics:    g.recover,
+		ReportCompletion: g.completion,
+		SkipNilRoutines:  g.skipNil,
+
+		NoTerminateOnCleanCompletion: g.skipClean,
+		IdempotentTerminate:          g.idempotent,
+		DebugDetectStuckTerminate:    g.stuck,
+		AbortGrace:                   g.grace,
+		DedupeErrors:                 g.dedupe,
+		AggregateWhenMultiple:        g.aggregate,
+		ReturnOnError:                g.detach,
+		IdleTimeout:                  g.idle,
+		MemoryBudget:                 g.memory,
+		Attribution:                  g.attribute,
+		MaxRecordedErrors:            g.maxErrs,
+		SeverityFloor:                g.floor,
+		HasSeverityFloor:             g.hasFloor,
+	}
+}
+
+// description is the JSON description of the configuration of a Group.
+type description struct {
+	Members []memberDescription `json:"members"`
+
+	Settings
+
+	HasErrorHandler      bool `json:"has_error_handler"`
+	HasErrorCtxHandler   bool `json:"has_error_ctx_handler"`
+	HasTerminatedHandler bool `json:"has_terminated_handler"`
+	HasBeforeTerminate   bool `json:"has_before_terminate"`
+	HasErrorCombiner     bool `json:"has_error_combiner"`
+	HasErrorPreference   bool `json:"has_error_preference"`
+	HasSeverity          bool `json:"has_severity"`
+	HasErrorDecorator    bool `json:"has_error_decorator"`
+	HasErrorMapper       bool `json:"has_error_mapper"`
+	HasPanicFormatter    bool `json:"has_panic_formatter"`
+	HasMiddleware        bool `json:"has_middleware"`
+	HasBaseContext       bool `json:"has_base_context"`
+	HasTracer            bool `json:"has_tracer"`
+	HasRateLimiter       bool `json:"has_rate_limiter"`
+	HasLogger            bool `json:"has_logger"`
+	HasPool              bool `json:"has_pool"`
+	HasClock             bool `json:"has_clock"`
+}
+
+// memberDescription is the JSON description of the configuration of a
+// member of a Group.
+type memberDescription struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+
+	Advisory    bool `json:"advisory,omitempty"`
+	Leader      bool `json:"leader,omitempty"`
+	Sentinel    bool `json:"sentinel,omitempty"`
+	Conditional bool `json:"conditional,omitempty"`
+	Scoped      bool `json:"scoped,omitempty"`
+
+	Workers        int    `json:"workers,omitempty"`
+	MemoryCost     int64  `json:"memory_cost,omitempty"`
+	Timeout        string `json:"timeout,omitempty"`
+	Deadline       string `json:"deadline,omitempty"`
+	HealthInterval string `json:"health_interval,omitempty"`
+	HealthFailures int    `json:"health_failures,omitempty"`
+
+	HasRoutine     bool `json:"has_routine"`
+	HasContext     bool `json:"has_context"`
+	HasTerminate   bool `json:"has_terminate"`
+	HasHealthCheck bool `json:"has_health_check"`
+}
+
+// DescribeJSON returns a JSON document describing the configuration of the
+// Group and its members, e.g. for attaching to a bug report.
+//
+// Only the static configuration of the Group is described, not the state
+// of a run. Functions cannot be serialized, so only whether they are set is
+// included.
+func (g *Group) DescribeJSON() ([]byte, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	d := description{
+		Members:              make([]memberDescription, len(g.members)),
+		Settings:             g.settings(),
+		HasErrorHandler:      g.onError != nil,
+		HasErrorCtxHandler:   g.onErrorCtx != nil,
+		HasTerminatedHandler: g.onTerminated != nil,
+		HasBeforeTerminate:   g.beforeTerm != nil,
+		HasErrorCombiner:     g.combine != nil,
+		HasErrorPreference:   g.prefer != nil,
+		HasSeverity:          g.severity != nil,
+		HasErrorDecorator:    g.decorate != nil,
+		HasErrorMapper:       g.mapErr != nil,
+		HasPanicFormatter:    g.formatPanic != nil,
+		HasMiddleware:        len(g.middleware) > 0,
+		HasBaseContext:       g.baseCtx != nil,
+		HasTracer:            g.tracer != nil,
+		HasRateLimiter:       g.limiter != nil,
+		HasLogger:            g.logger != nil,
+		HasPool:              g.pool != nil,
+		HasClock:             g.clk != nil,
+	}
+	for i, m := range g.members {
+		md := memberDescription{
+			Name:           m.name,
+			Labels:         m.labels,
+			Advisory:       m.advisory,
+			Leader:         m.leader,
+			Sentinel:       m.sentinel,
+			Conditional:    m.enabled != nil,
+			Scoped:         m.scope != nil,
+			Workers:        m.workers,
+			MemoryCost:     m.cost,
+			HealthFailures: m.failures,
+			HasRoutine:     m.routine != nil || m.ctxRoutine != nil,
+			HasContext:     m.ctxRoutine != nil,
+			HasTerminate:   m.terminate != nil,
+			HasHealthCheck: m.health != nil,
+		}
+		if m.timeout > 0 {
+			md.Timeout = m.timeout.String()
+		}
+		if !m.deadline.IsZero() {
+			md.Deadline = m.deadline.Format(time.RFC3339Nano)
+		}
+		if m.health != nil {
+			md.HealthInterval = m.interval.String()
+		}
+		d.Members[i] = md
+	}
+	return json.Marshal(d)
+}
@@ -0,0 +1,128 @@
+package errgroup
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGroup_DescribeJSON(t *testing.T) {
+	var g Group
+	g.StopAfterN(1)
+	g.AddNamed(
+		"one",
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+	g.AddWithTimeout(
+		func() error {
+			return nil
+		},
+		nil,
+		time.Second,
+	)
+
+	data, err := g.DescribeJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var d description
+	if err := json.Unmarshal(data, &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.StopAfterN != 1 {
+		t.Errorf("unexpected stop after n: %d", d.StopAfterN)
+	}
+	if len(d.Members) != 2 {
+		t.Fatalf("unexpected members: %v", d.Members)
+	}
+	if d.Members[0].Name != "one" || !d.Members[0].HasTerminate {
+		t.Errorf("unexpected member description: %+v", d.Members[0])
+	}
+	if d.Members[1].Timeout != "1s" || d.Members[1].HasTerminate {
+		t.Errorf("unexpected member description: %+v", d.Members[1])
+	}
+}
+
+func TestGroup_Settings(t *testing.T) {
+	var g Group
+	if s := g.Settings(); s != (Settings{}) {
+		t.Errorf("unexpected default settings: %+v", s)
+	}
+
+	g.StopAfterN(2)
+	g.StartBarrier(true)
+	g.RecoverPanics(true)
+	g.SetSeverityFloor(0)
+
+	expected := Settings{
+		StopAfterN:       2,
+		StartBarrier:     true,
+		RecoverPanics:    true,
+		HasSeverityFloor: true,
+	}
+	if s := g.Settings(); s != expected {
+		t.Errorf("unexpected settings: %+v", s)
+	}
+}
+
+func TestGroup_SettingsCoverOptions(t *testing.T) {
+	// Each option is either reported in Settings or, for options which
+	// cannot be serialized, by whether it is set in DescribeJSON.
+	covered := map[string]string{
+		"onError":      "HasErrorHandler",
+		"onErrorCtx":   "HasErrorCtxHandler",
+		"onTerminated": "HasTerminatedHandler",
+		"beforeTerm":   "HasBeforeTerminate",
+		"combine":      "HasErrorCombiner",
+		"dedupe":       "DedupeErrors",
+		"aggregate":    "AggregateWhenMultiple",
+		"detach":       "ReturnOnError",
+		"maxErrs":      "MaxRecordedErrors",
+		"prefer":       "HasErrorPreference",
+		"severity":     "HasSeverity",
+		"floor":        "SeverityFloor",
+		"hasFloor":     "HasSeverityFloor",
+		"stuck":        "DebugDetectStuckTerminate",
+		"grace":        "AbortGrace",
+		"idle":         "IdleTimeout",
+		"decorate":     "HasErrorDecorator",
+		"mapErr":       "HasErrorMapper",
+		"attribute":    "Attribution",
+		"barrier":      "StartBarrier",
+		"recover":      "RecoverPanics",
+		"formatPanic":  "HasPanicFormatter",
+		"middleware":   "HasMiddleware",
+		"completion":   "ReportCompletion",
+		"quorum":       "StopAfterN",
+		"firstNil":     "StopOnFirstNil",
+		"skipNil":      "SkipNilRoutines",
+		"skipClean":    "NoTerminateOnCleanCompletion",
+		"idempotent":   "IdempotentTerminate",
+		"baseCtx":      "HasBaseContext",
+		"tracer":       "HasTracer",
+		"limiter":      "HasRateLimiter",
+		"logger":       "HasLogger",
+		"pool":         "HasPool",
+		"memory":       "MemoryBudget",
+		"clk":          "HasClock",
+	}
+
+	opts := reflect.TypeOf(options{})
+	desc := reflect.TypeOf(description{})
+	for i := 0; i < opts.NumField(); i++ {
+		name := opts.Field(i).Name
+		field, ok := covered[name]
+		if !ok {
+			t.Errorf("option %s is not described", name)
+			continue
+		}
+		if _, ok := desc.FieldByName(field); !ok {
+			t.Errorf("option %s: no field %s", name, field)
+		}
+	}
+}
@@ -4,12 +4,362 @@
 // cause the group to terminate.
 package errgroup
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRunning is returned by Run when the Group is already running.
+var ErrRunning = errors.New("errgroup: group is already running")
+
+// ErrAllCompleted is returned by Run when every member of a Group returns
+// cleanly and completion is reported; see ReportCompletion.
+var ErrAllCompleted = errors.New("errgroup: all members completed")
+
+// ErrNoMembers is returned by Run when a Group without members is run and
+// completion is reported; see ReportCompletion.
+var ErrNoMembers = errors.New("errgroup: no members to run")
+
+// ErrMemberTimeout is reported for a member whose routine did not return
+// within its timeout.
+var ErrMemberTimeout = errors.New("errgroup: member timed out")
+
+// ErrHedgeLost is the cause with which the contexts of members are canceled
+// when the Group terminates because the routines of other members reached
+// the quorum set by StopAfterN or StopOnFirstNil, so routines can tell that
+// they lost the race from other shutdowns with context.Cause.
+var ErrHedgeLost = errors.New("errgroup: another member succeeded first")
+
+// ErrPoolExhausted is returned by Run when the pool set by SetPool cannot
+// accept the routine of a member.
+var ErrPoolExhausted = errors.New("errgroup: pool exhausted")
+
+// ErrMemberDeadline is reported for a member whose routine did not return by
+// its deadline.
+var ErrMemberDeadline = errors.New("errgroup: member missed its deadline")
+
+// Reason describes why a run of a Group stopped.
+type Reason int
+
+// The reasons a run of a Group may stop.
+const (
+	// ReasonNone indicates that the Group has not finished a run.
+	ReasonNone Reason = iota
+
+	// ReasonCompleted indicates that the run finished cleanly and Run
+	// returned nil, or ErrAllCompleted if completion is reported.
+	ReasonCompleted
+
+	// ReasonMemberError indicates that the run was terminated by an error
+	// returned from a member routine.
+	ReasonMemberError
+
+	// ReasonTimeout indicates that the run was terminated because a member
+	// routine did not return within its timeout or by its deadline.
+	ReasonTimeout
+
+	// ReasonStopped indicates that the run was terminated by Stop.
+	ReasonStopped
+
+	// ReasonIdle indicates that the run was terminated because no member
+	// reported activity within the idle timeout.
+	ReasonIdle
+)
+
+// String returns a short description of the reason, e.g. "member error".
+func (r Reason) String() string {
+	switch r {
+	case ReasonNone:
+		return "none"
+	case ReasonCompleted:
+		return "completed"
+	case ReasonMemberError:
+		return "member error"
+	case ReasonTimeout:
+		return "timeout"
+	case ReasonStopped:
+		return "stopped"
+	case ReasonIdle:
+		return "idle"
+	}
+	return fmt.Sprintf("Reason(%d)", int(r))
+}
+
+// ErrUnhealthy is reported for a member which failed too many consecutive
+// health checks.
+var ErrUnhealthy = errors.New("errgroup: member unhealthy")
+
+// PanicError is the error reported for a member routine which panicked
+// when panic recovery is enabled for the Group.
+type PanicError struct {
+	// Name is the name of the member which panicked, if it has one.
+	Name string
+
+	// Value is the value passed to panic.
+	Value interface{}
+
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+// Error returns the error message, naming the member if it has a name.
+func (e *PanicError) Error() string {
+	if e.Name == "" {
+		return fmt.Sprintf("errgroup: member panicked: %v", e.Value)
+	}
+	return fmt.Sprintf("errgroup: member %q panicked: %v", e.Name, e.Value)
+}
+
+// MemberError attributes an error returned by a member routine to the
+// member when attribution is enabled for the Group; see SetAttribution.
+type MemberError struct {
+	// Name is the name of the member, if it has one.
+	Name string
+
+	// Err is the error returned by the member's routine.
+	Err error
+}
+
+// Error returns the member's error prefixed with its name, if it has one.
+func (e *MemberError) Error() string {
+	if e.Name == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %v", e.Name, e.Err)
+}
+
+// Unwrap returns the error returned by the member's routine.
+func (e *MemberError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError is the error returned by Run when several member routines
+// returned errors and aggregation is enabled for the Group, see
+// AggregateWhenMultiple, or the quorum set by StopAfterN or StopOnFirstNil
+// became unreachable.
+type MultiError struct {
+	// Errors are the errors returned by member routines, in the order they
+	// were received. If AggregateWhenMultiple is enabled, the error which
+	// triggered termination comes first and the errors reported by
+	// CleanupErrors are included last.
+	Errors []error
+}
+
+// Error returns the number of errors followed by each of their messages.
+func (e *MultiError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("errgroup: %d errors: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors held by the MultiError, so any of them can be
+// matched with errors.Is and errors.As.
+func (e *MultiError) Unwrap() []error {
+	return e.Errors
+}
+
 // member is a member of a group. It defines the function which will
 // be run within a goroutine and a function which will be called on
-// group termination.
+// group termination. Members may optionally be named so they can be
+// referenced after they are added.
 type member struct {
-	routine   func() error
-	terminate func(error)
+	name       string
+	routine    func() error
+	ctxRoutine func(ctx context.Context) error
+	terminate  func(error)
+
+	// scope, if set, bounds the lifetime of the member. Once it is done,
+	// the member is stopped and removed from the group.
+	scope context.Context
+
+	// enabled, if set, is evaluated at the start of each run to decide
+	// whether the member is run at all.
+	enabled func() bool
+
+	// timeout, if hasTimeout is set, bounds how long the member's routine
+	// may run.
+	timeout    time.Duration
+	hasTimeout bool
+
+	// deadline, if set, is the time by which the member's routine must
+	// return.
+	deadline time.Time
+
+	// health, if set, is checked every interval while the member's routine
+	// runs. The member fails after the given number of consecutive failed
+	// checks.
+	health   func() error
+	interval time.Duration
+	failures int
+
+	// workers is the number of copies of the routine run concurrently as
+	// the member, if hasWorkers is set.
+	workers    int
+	hasWorkers bool
+
+	// advisory members report errors without triggering termination.
+	advisory bool
+
+	// leader members trigger termination whenever they return.
+	leader bool
+
+	// sentinel members trigger termination when they return nil.
+	sentinel bool
+
+	// relay members run other members, such as the members streamed to
+	// RunStream, and return their errors, which have already been reported.
+	// They are not reported or counted as members themselves.
+	relay bool
+
+	// labels, if set, tag the member for metrics, tracing and logging.
+	labels map[string]string
+
+	// cost is the estimated memory used by the member's routine, counted
+	// against the memory budget of the group.
+	cost int64
+}
+
+// Actor describes a member of a Group.
+type Actor struct {
+	// Name is the name of the member. It may be empty.
+	Name string
+
+	// Routine is run within a goroutine when the Group runs.
+	Routine func() error
+
+	// Terminate is called on Group termination. It should cause Routine
+	// to return.
+	Terminate func(error)
+}
+
+// Tracer starts tracing spans around member routines. It is a minimal
+// interface which can be implemented on top of a tracing library such as
+// OpenTelemetry.
+type Tracer interface {
+	// StartSpan starts a span with the given name as a child of ctx. It
+	// returns the context carrying the span and a function which ends the
+	// span with the error returned by the traced routine.
+	StartSpan(ctx context.Context, name string) (context.Context, func(error))
+}
+
+// Limiter throttles work shared between the members of a Group. It is
+// satisfied by *rate.Limiter from golang.org/x/time/rate.
+type Limiter interface {
+	// Wait blocks until work may proceed or ctx is done.
+	Wait(ctx context.Context) error
+}
+
+// limiterKey is the context key for the Limiter of a Group.
+type limiterKey struct{}
+
+// RateLimiter returns the Limiter shared by the members of a Group from the
+// context passed to a member added with AddCtx, or nil if the Group has no
+// Limiter.
+func RateLimiter(ctx context.Context) Limiter {
+	l, _ := ctx.Value(limiterKey{}).(Limiter)
+	return l
+}
+
+// labelsKey is the context key for the labels of a member.
+type labelsKey struct{}
+
+// MemberLabels returns the labels of a member added with AddLabeled from the
+// context passed to a Tracer starting a span around it, or nil if the member
+// has no labels. The returned map must not be modified.
+func MemberLabels(ctx context.Context) map[string]string {
+	labels, _ := ctx.Value(labelsKey{}).(map[string]string)
+	return labels
+}
+
+// MemberResult is the result of a member's routine.
+type MemberResult struct {
+	// Name is the name of the member, if it has one.
+	Name string
+
+	// Err is the error returned by the member's routine.
+	Err error
+}
+
+// spawner tracks the goroutines spawned by members during a run.
+type spawner struct {
+	wg *sync.WaitGroup
+
+	// failed receives the first error returned by a spawned goroutine.
+	failed chan error
+}
+
+// state is the state of a member during a run.
+type state struct {
+	cancel context.CancelCauseFunc
+
+	// done is closed once the member's routine has exited.
+	done chan struct{}
+
+	// terminating is set once the member's terminate function has been
+	// called, either when the group terminates or by StopMember.
+	terminating bool
+
+	// stopped is set if the member was stopped by StopMember.
+	stopped bool
+
+	// called is set once the member's terminate function has been called
+	// if IdempotentTerminate is enabled, and cleared when its routine is
+	// called again by a scheduled member.
+	called bool
+}
+
+// result is the outcome of a member's routine.
+type result struct {
+	member *member
+	err    error
+}
+
+// options holds the configuration of a Group, other than its members.
+type options struct {
+	onError      func(err error)
+	onErrorCtx   func(ctx context.Context, err error)
+	onTerminated func(name string, err error)
+	beforeTerm   func(cause error) error
+	combine      func(errs []error) error
+	dedupe       bool
+	aggregate    bool
+	detach       bool
+	maxErrs      int
+	prefer       func(a, b error) bool
+	severity     func(err error) int
+	floor        int
+	hasFloor     bool
+	stuck        time.Duration
+	grace        time.Duration
+	idle         time.Duration
+	decorate     func(name string, err error) error
+	mapErr       func(err error) error
+	attribute    bool
+	barrier      bool
+	recover      bool
+	formatPanic  func(name string, recovered interface{}, stack []byte) error
+	middleware   []func(name string, routine func() error) func() error
+	completion   bool
+	quorum       int
+	firstNil     bool
+	skipNil      bool
+	skipClean    bool
+	idempotent   bool
+	baseCtx      context.Context
+	tracer       Tracer
+	limiter      Limiter
+	logger       Logger
+	pool         func(task func()) error
+	memory       int64
+	clk          Clock
 }
 
 // Group holds a collection of members which whose routines are run
@@ -17,8 +367,68 @@ type member struct {
 // Group to terminate.
 type Group struct {
 	members []*member
+	options
+
+	// mu guards the Group's members and its run state so that the Group
+	// can be inspected while it is running.
+	mu      sync.Mutex
+	running bool
+	states  map[*member]*state
+	spawner *spawner
+	results chan MemberResult
+	started chan struct{}
+	stop    chan struct{}
+	retry   chan struct{}
+	done    chan struct{}
+	err     error
+	errs    []error
+	dropped int
+	cleanup []error
+	total   int
+	exited  int
+	all     bool
+	order   []string
+	reason  Reason
+	began   time.Time
+	elapsed time.Duration
+	timings []MemberReport
+	values  map[interface{}]interface{}
+	winner  string
+	events  chan lifecycleEvent
+	sink    chan<- MemberResult
+
+	once    sync.Once
+	onceErr error
+
+	// hook, if set, is called at points of interest during a run so that
+	// tests can observe and control the order in which members run and
+	// terminate without relying on sleeps.
+	hook func(p hookPoint, m *member)
+}
+
+// hookPoint identifies a point in a run at which the hook of a Group is
+// called.
+type hookPoint int
 
-	onError func(err error)
+const (
+	// hookStart is reached in a member's goroutine just before its routine
+	// is called.
+	hookStart hookPoint = iota
+
+	// hookReturn is reached in a member's goroutine just after its routine
+	// returns, before the result is delivered to the run.
+	hookReturn
+
+	// hookTerminate is reached just before a member's terminate function is
+	// called when the Group terminates.
+	hookTerminate
+)
+
+// at calls the hook of the Group, if any, for the given point and member.
+func (g *Group) at(p hookPoint, m *member) {
+	if g.hook != nil {
+		g.hook(p, m)
+	}
 }
 
 // Add a new member to the Group.
@@ -26,9 +436,400 @@ type Group struct {
 // All members should define a routine to be called and a termination
 // function. The termination function should cause the member's routine
 // to return. Additionally, it should be safe to call the terminate function
-// after the routine has returned.
+// after the routine has returned, and more than once unless
+// IdempotentTerminate is enabled.
 func (g *Group) Add(routine func() error, terminate func(error)) {
-	g.members = append(g.members, &member{routine, terminate})
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.members = append(g.members, &member{routine: routine, terminate: terminate})
+}
+
+// Clone returns a new Group with a copy of the member definitions and
+// options of the Group, e.g. to run several independent instances of a
+// template Group.
+//
+// The clone is not running, regardless of the state of the Group, and running
+// it does not affect the Group or any other clone. Member routines and
+// terminate functions are shared rather than copied, so they should not rely
+// on state which is not safe to share between instances.
+func (g *Group) Clone() *Group {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	c := &Group{
+		members: make([]*member, len(g.members)),
+		options: g.options,
+	}
+	for i, m := range g.members {
+		copied := *m
+		c.members[i] = &copied
+	}
+	return c
+}
+
+// Merge returns a new Group with a copy of the member definitions of all of
+// the given groups, e.g. to wire groups defined in separate packages into a
+// single supervised unit. An error from any member terminates the whole
+// merged Group.
+//
+// Each option of the merged Group takes its value from the first of the
+// groups which sets it; options set on later groups are ignored where an
+// earlier group has already set them. As with Clone, the merged Group is not
+// running regardless of the state of the given groups, and member names
+// must be unique across all of them for the merged Group to run.
+func Merge(groups ...*Group) *Group {
+	merged := &Group{}
+	for _, g := range groups {
+		g.mu.Lock()
+		merged.options.merge(g.options)
+		for _, m := range g.members {
+			copied := *m
+			merged.members = append(merged.members, &copied)
+		}
+		g.mu.Unlock()
+	}
+	return merged
+}
+
+// merge sets each option which is not set on o to its value in other.
+func (o *options) merge(other options) {
+	if o.onError == nil {
+		o.onError = other.onError
+	}
+	if o.onErrorCtx == nil {
+		o.onErrorCtx = other.onErrorCtx
+	}
+	if o.onTerminated == nil {
+		o.onTerminated = other.onTerminated
+	}
+	if o.beforeTerm == nil {
+		o.beforeTerm = other.beforeTerm
+	}
+	if o.combine == nil {
+		o.combine = other.combine
+	}
+	if o.prefer == nil {
+		o.prefer = other.prefer
+	}
+	if o.decorate == nil {
+		o.decorate = other.decorate
+	}
+	if o.severity == nil {
+		o.severity = other.severity
+	}
+	if o.stuck <= 0 {
+		o.stuck = other.stuck
+	}
+	if o.grace <= 0 {
+		o.grace = other.grace
+	}
+	if o.idle <= 0 {
+		o.idle = other.idle
+	}
+	if !o.hasFloor {
+		o.floor, o.hasFloor = other.floor, other.hasFloor
+	}
+	if o.mapErr == nil {
+		o.mapErr = other.mapErr
+	}
+	if o.formatPanic == nil {
+		o.formatPanic = other.formatPanic
+	}
+	if o.middleware == nil {
+		o.middleware = other.middleware
+	}
+	if o.quorum <= 0 {
+		o.quorum = other.quorum
+	}
+	if o.baseCtx == nil {
+		o.baseCtx = other.baseCtx
+	}
+	if o.tracer == nil {
+		o.tracer = other.tracer
+	}
+	if o.limiter == nil {
+		o.limiter = other.limiter
+	}
+	if o.logger == nil {
+		o.logger = other.logger
+	}
+	if o.pool == nil {
+		o.pool = other.pool
+	}
+	if o.memory <= 0 {
+		o.memory = other.memory
+	}
+	if o.clk == nil {
+		o.clk = other.clk
+	}
+	o.barrier = o.barrier || other.barrier
+	o.recover = o.recover || other.recover
+	o.completion = o.completion || other.completion
+	o.firstNil = o.firstNil || other.firstNil
+	o.dedupe = o.dedupe || other.dedupe
+	o.aggregate = o.aggregate || other.aggregate
+	o.detach = o.detach || other.detach
+	if o.maxErrs <= 0 {
+		o.maxErrs = other.maxErrs
+	}
+	o.attribute = o.attribute || other.attribute
+	o.skipNil = o.skipNil || other.skipNil
+	o.skipClean = o.skipClean || other.skipClean
+	o.idempotent = o.idempotent || other.idempotent
+}
+
+// AddWaitFor adds a new named member to the Group which mirrors the
+// lifecycle of another Group, e.g. to stop a parent Group when a sibling
+// Group stops.
+//
+// The member's routine waits for the current run of other to finish, or
+// its next run if other is not running, and returns its error, as returned
+// by LastError; a run of other which finished before the member started is
+// not waited for. Its terminate function stops other. A Group must not wait
+// for itself.
+func (g *Group) AddWaitFor(name string, other *Group) {
+	g.AddNamedCtx(
+		name,
+		func(ctx context.Context) error {
+			select {
+			case <-other.Done():
+				return other.LastError()
+			case <-ctx.Done():
+				return nil
+			}
+		},
+		func(error) {
+			other.Stop()
+		},
+	)
+}
+
+// Preallocate reserves space for n members in the Group, reducing
+// allocations when a large Group is assembled with many calls to Add. It
+// does not otherwise change the behavior of the Group.
+func (g *Group) Preallocate(n int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if cap(g.members) >= n {
+		return
+	}
+	members := make([]*member, len(g.members), n)
+	copy(members, g.members)
+	g.members = members
+}
+
+// AddNamed adds a new named member to the Group.
+//
+// It behaves the same as Add, but the member may later be referenced by
+// its name.
+func (g *Group) AddNamed(name string, routine func() error, terminate func(error)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.members = append(g.members, &member{name: name, routine: routine, terminate: terminate})
+}
+
+// AddCtx adds a new member to the Group whose routine takes a context.
+//
+// The context is canceled when the Group terminates its members, prior to
+// calling the member's terminate function, so a routine which returns once
+// its context is done may use a no-op terminate function. The cause of the
+// cancellation, as reported by context.Cause, is the error which triggered
+// termination, or context.Canceled if there is none.
+func (g *Group) AddCtx(routine func(ctx context.Context) error, terminate func(error)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.members = append(g.members, &member{ctxRoutine: routine, terminate: terminate})
+}
+
+// AddNamedCtx adds a new named member to the Group whose routine takes a
+// context. It behaves the same as AddCtx, but the member may later be
+// referenced by its name.
+func (g *Group) AddNamedCtx(name string, routine func(ctx context.Context) error, terminate func(error)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.members = append(g.members, &member{name: name, ctxRoutine: routine, terminate: terminate})
+}
+
+// AddLabeled adds a new named member to the Group tagged with the given
+// labels, e.g. its subsystem or region, for richer metrics and dashboards.
+//
+// It behaves the same as AddNamed. The labels are available to the Group's
+// Tracer from the context of the member's span through MemberLabels, and
+// are included in the Group's Report and DescribeJSON.
+// The labels are copied, so later changes to the map do not affect the
+// member.
+func (g *Group) AddLabeled(name string, labels map[string]string, routine func() error, terminate func(error)) {
+	copied := make(map[string]string, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.members = append(g.members, &member{name: name, labels: copied, routine: routine, terminate: terminate})
+}
+
+// AddScoped adds a new named member to the Group whose lifetime is bound to
+// ctx, e.g. for a plugin which may be unloaded.
+//
+// The member runs like any other member, but once ctx is done it is stopped
+// individually, as with StopMember, without terminating the rest of the
+// Group, and it is removed from the Group.
+func (g *Group) AddScoped(ctx context.Context, name string, routine func() error, terminate func(error)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.members = append(g.members, &member{name: name, routine: routine, terminate: terminate, scope: ctx})
+}
+
+// AddWorkers adds a new named member to the Group which runs count copies of
+// its routine concurrently, e.g. to process a queue with several workers.
+//
+// The workers are treated as a single member. The member fails when any of
+// its workers returns an error, in which case terminate is called once to
+// stop the remaining workers, and the member reports the first error once
+// they have all returned. When the Group terminates, terminate is called
+// once for the member and should stop all of its workers. The count must be
+// positive.
+func (g *Group) AddWorkers(name string, count int, routine func() error, terminate func(error)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.members = append(g.members, &member{name: name, routine: routine, terminate: terminate, workers: count, hasWorkers: true})
+}
+
+// AddAdvisory adds a new advisory member to the Group.
+//
+// An advisory member's routine may return an error to report a condition
+// without terminating the Group. Its errors are recorded and available via
+// Errors, but only errors from non-advisory members trigger termination.
+func (g *Group) AddAdvisory(routine func() error, terminate func(error)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.members = append(g.members, &member{routine: routine, terminate: terminate, advisory: true})
+}
+
+// AddBestEffort adds a new named best-effort member to the Group, e.g. for a
+// job in a batch which is allowed to fail without failing the batch.
+//
+// A best-effort member is an advisory member with a name: its errors are
+// recorded and available via Errors, but they do not trigger termination or
+// affect the error returned by Run, which returns nil if only best-effort
+// members failed. Members added with Add and its other variants remain
+// critical.
+func (g *Group) AddBestEffort(name string, routine func() error, terminate func(error)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.members = append(g.members, &member{name: name, routine: routine, terminate: terminate, advisory: true})
+}
+
+// AddLeader adds a new leader member to the Group.
+//
+// Unlike other members, whose routines only trigger termination of the Group
+// when they return an error, a leader triggers termination whenever its
+// routine returns. If it returns nil, the Group terminates cleanly. A Group
+// may have multiple leaders, in which case the first to return terminates
+// the Group.
+func (g *Group) AddLeader(routine func() error, terminate func(error)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.members = append(g.members, &member{routine: routine, terminate: terminate, leader: true})
+}
+
+// AddSentinel adds a new completion sentinel member to the Group, e.g. a
+// migration job which the other members should run until it finishes.
+//
+// When a sentinel's routine returns nil, the Group terminates cleanly, while
+// nil returns from other members are ignored as usual. Unlike a leader, an
+// error from a sentinel is handled in the same way as an error from any
+// other member.
+func (g *Group) AddSentinel(routine func() error, terminate func(error)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.members = append(g.members, &member{routine: routine, terminate: terminate, sentinel: true})
+}
+
+// AddWithTimeout adds a new member to the Group whose routine may run for at
+// most d.
+//
+// If the routine has not returned within d, its terminate function is called
+// early to unblock it. Once the routine returns, or the grace period set by
+// SetAbortGrace passes, the member is treated as having returned an error
+// wrapping ErrMemberTimeout, which names the member if it has a name. The
+// duration d must be positive.
+func (g *Group) AddWithTimeout(routine func() error, terminate func(error), d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.members = append(g.members, &member{routine: routine, terminate: terminate, timeout: d, hasTimeout: true})
+}
+
+// AddWithAbsoluteDeadline adds a new named member to the Group whose
+// routine must return by the time at, regardless of when it started, e.g.
+// to bound a member by a budget measured from when the Group started.
+//
+// If the routine has not returned by at, as told by the Group's Clock, its
+// terminate function is called early to unblock it. Once the routine
+// returns, the member is treated as having returned an error wrapping
+// ErrMemberDeadline, which names the member if it has a name. Unlike
+// AddWithTimeout, the deadline does not move when the Group is run again.
+func (g *Group) AddWithAbsoluteDeadline(name string, at time.Time, routine func() error, terminate func(error)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.members = append(g.members, &member{name: name, routine: routine, terminate: terminate, deadline: at})
+}
+
+// AddWatched adds a new named member to the Group whose health is actively
+// monitored while its routine runs.
+//
+// The health function is called every interval. If it returns a non-nil
+// error for the given number of consecutive checks, the member is treated as
+// failed: its terminate function is called to unblock its routine and, once
+// the routine returns, the member reports an error wrapping ErrUnhealthy and
+// the last health check error.
+func (g *Group) AddWatched(name string, routine func() error, health func() error, terminate func(error), interval time.Duration, failures int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.members = append(g.members, &member{
+		name:      name,
+		routine:   routine,
+		terminate: terminate,
+		health:    health,
+		interval:  interval,
+		failures:  failures,
+	})
+}
+
+// AddConditional adds a new named member to the Group which is only run if
+// enabled returns true.
+//
+// The enabled function is evaluated at the start of each run. If it returns
+// false, the member is skipped entirely for that run: its routine is not
+// started and its terminate function is not called.
+func (g *Group) AddConditional(name string, enabled func() bool, routine func() error, terminate func(error)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.members = append(g.members, &member{name: name, routine: routine, terminate: terminate, enabled: enabled})
+}
+
+// Replace the routine and terminate functions of the named member.
+//
+// This is intended for substituting a member's behavior, e.g. with a mock
+// in tests, without rebuilding the rest of the Group. The routine replaces
+// the member's routine even if it was added with a context, e.g. with
+// AddNamedCtx. It returns whether a member with the given name exists.
+// Replace has no effect while the Group is running, in which case it returns
+// false.
+func (g *Group) Replace(name string, routine func() error, terminate func(error)) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.running {
+		return false
+	}
+	for _, m := range g.members {
+		if m.name == name {
+			m.routine = routine
+			m.ctxRoutine = nil
+			m.terminate = terminate
+			return true
+		}
+	}
+	return false
 }
 
 // OnError registers an error handler with the Group.
@@ -36,63 +837,2171 @@ func (g *Group) Add(routine func() error, terminate func(error)) {
 // The error handler is optional and is run prior to terminating members of the
 // group. It can be used for things like logging out the trapped error.
 func (g *Group) OnError(handler func(err error)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
 	g.onError = handler
 }
 
-// Run the routines of all Group members concurrently.
+// OnErrorCtx registers an error handler with the Group which also receives
+// a context, e.g. to emit a trace event in the same scope as the run.
 //
-// If a routine terminates with a nil error, the other members will continue
-// to run. When the first non-nil error is returned from a member routine, all
-// members of the Group will be terminated. This function does not return until
-// all members have terminated. Once all members terminate, this will return
-// the error which triggered the group termination.
+// The context is the base context set by WithBaseContext, or
+// context.Background if none is set. The handler is called at the same
+// point as the handler registered by OnError; if both are set, both are
+// called, the OnError handler first.
+func (g *Group) OnErrorCtx(handler func(ctx context.Context, err error)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.onErrorCtx = handler
+}
+
+// OnTerminated registers a handler which is called as each member finishes
+// terminating.
 //
-// Note that if a member routine returns a nil error, its terminate function
-// will not be called until a non-nil error is returned by another member of
-// the group.
-func (g *Group) Run() error {
-	// If there are no members of the group, there is nothing to do.
-	if len(g.members) == 0 {
-		return nil
-	}
+// The handler is called once for every member of the Group, after the
+// member's terminate function has returned and its routine has exited. It
+// receives the member's name and the error which triggered termination.
+func (g *Group) OnTerminated(handler func(name string, err error)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.onTerminated = handler
+}
 
-	// Run the goroutine for each member of the group.
-	errors := make(chan error, len(g.members))
-	for _, m := range g.members {
-		go func(m *member) {
-			errors <- m.routine()
-		}(m)
-	}
+// BeforeTerminate registers a function which is called when the Group is
+// about to terminate, e.g. to deregister from service discovery and wait
+// for the change to propagate before members start shutting down.
+//
+// The function is called once per run, after the error handlers and before
+// any member is terminated, and termination waits for it to return. It
+// receives the error which triggered termination, which is nil if the Group
+// was stopped or completed cleanly. If it returns an error, the error is
+// reported by CleanupErrors and termination proceeds as usual. It is not
+// called if the terminate functions are skipped on a clean completion.
+func (g *Group) BeforeTerminate(fn func(cause error) error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.beforeTerm = fn
+}
 
-	// Wait for the first non-nil error returned.
-	var terminated int
-	var err error
-	for e := range errors {
-		terminated++
-		if e != nil {
-			err = e
-			break
-		}
-		if terminated == cap(errors) {
-			break
-		}
-	}
+// TerminateOrder returns the names of the members whose terminate functions
+// were called during the most recent run, in the order they were called,
+// e.g. to check that termination happened in the intended order. Unnamed
+// members are listed with an empty name, and a member is listed again each
+// time its terminate function is called.
+func (g *Group) TerminateOrder() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	order := make([]string, len(g.order))
+	copy(order, g.order)
+	return order
+}
 
-	// If an error handler is specified and there is an error,
-	// execute the handler function.
-	if err != nil && g.onError != nil {
-		g.onError(err)
-	}
+// CleanupErrors returns the errors which occurred while the most recent run
+// of the Group was shutting down, such as an error returned by the function
+// registered with BeforeTerminate or a panic recovered from a terminate
+// function. They are not included in Errors, and are only returned by Run
+// if AggregateWhenMultiple is enabled.
+func (g *Group) CleanupErrors() []error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	errs := make([]error, len(g.cleanup))
+	copy(errs, g.cleanup)
+	return errs
+}
 
-	// Terminate all group members.
-	for _, member := range g.members {
-		member.terminate(err)
-	}
+// recordCleanup records an error which occurred while the current run was
+// shutting down.
+func (g *Group) recordCleanup(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.cleanup = append(g.cleanup, err)
+}
+
+// WithBaseContext sets the context from which the contexts passed to the
+// routines of members added with AddCtx are derived. By default, they are
+// derived from context.Background.
+//
+// Values and deadlines of the base context are inherited by each member's
+// context, which is still canceled when the Group terminates its members.
+func (g *Group) WithBaseContext(ctx context.Context) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.baseCtx = ctx
+}
+
+// SetTracer sets the Tracer used to trace member routines.
+//
+// When set, Run starts a span named after each member around its routine and
+// ends it with the routine's error. The routine of a member added with
+// AddCtx receives the context carrying its span.
+func (g *Group) SetTracer(tracer Tracer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.tracer = tracer
+}
+
+// SetPool sets a function which runs member routines on a goroutine pool
+// rather than in new goroutines, e.g. to bound the total number of
+// goroutines across many groups.
+//
+// Each member's routine is passed to submit as a task when the Group runs.
+// If submit returns an error, e.g. because the pool is exhausted, the
+// remaining members are not submitted, all members are terminated, and Run
+// returns an error wrapping both ErrPoolExhausted and the error from
+// submit. As for every member of a run, the terminate functions of members
+// which were not submitted are still called, so resources they acquired when
+// they were added are released. Goroutines used internally by the Group, e.g.
+// for AddWorkers, are not run on the pool. By default member routines run
+// in new goroutines.
+func (g *Group) SetPool(submit func(task func()) error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.pool = submit
+}
+
+// SetRateLimiter sets a Limiter shared by all members of the Group, e.g. to
+// throttle the combined rate of requests they make to an external service.
+//
+// The Limiter is carried by the contexts passed to the routines of members
+// added with AddCtx, which retrieve it with RateLimiter and Wait on it.
+func (g *Group) SetRateLimiter(l Limiter) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.limiter = l
+}
+
+// SetErrorCombiner registers a function which combines the errors collected
+// during a run into the single error returned by Run.
+//
+// When a run is terminated by an error, the combiner is called with all of
+// the non-nil errors returned by member routines, as reported by Errors. If
+// no combiner is set, Run returns the error which triggered termination.
+func (g *Group) SetErrorCombiner(combine func(errs []error) error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.combine = combine
+}
+
+// AggregateWhenMultiple sets whether Run returns a *MultiError holding all
+// of the errors returned by member routines, along with the errors reported
+// by CleanupErrors, when a run fails with more than one of them. The error
+// which triggered termination comes first, followed by the other routine
+// errors and then the cleanup errors. When there is only one error, it is
+// returned unchanged.
+//
+// Routine errors are collapsed first if DedupeErrors is enabled, and an
+// error combiner set with SetErrorCombiner takes precedence. It is disabled
+// by default.
+func (g *Group) AggregateWhenMultiple(enabled bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.aggregate = enabled
+}
+
+// SetAttribution sets whether errors returned by member routines are
+// wrapped in a *MemberError naming the member, so the member which caused
+// the error returned by Run can be found with errors.As without aggregating
+// errors. The wrapped error still matches the original with errors.Is.
+//
+// Errors are wrapped after the error decorator is applied, so the errors
+// reported by Errors and passed to the error combiner are wrapped as well.
+// It is disabled by default.
+func (g *Group) SetAttribution(enabled bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.attribute = enabled
+}
+
+// MapError registers a function which transforms the error returned by Run,
+// e.g. to translate it into a domain-specific error, or to return nil for
+// any run which a caller treats as a normal shutdown.
+//
+// The function is called once at the end of every run with the error Run is
+// about to return, including nil, after the error combiner and completion
+// reporting have been applied. The error it returns is returned by Run and
+// by LastError. It is not called if the Group could not be run, e.g.
+// because it is already running or is misconfigured.
+func (g *Group) MapError(mapper func(err error) error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.mapErr = mapper
+}
+
+// MaxRecordedErrors sets the maximum number of member errors recorded
+// during a run, bounding the memory used when a large Group fails in a
+// cascade. Errors beyond the limit are dropped, and counted by
+// DroppedErrors.
+//
+// The error which triggered termination is always recorded; if it would be
+// dropped, it replaces the most recently recorded error instead. The limit
+// applies to the errors reported by Errors and passed to the error
+// combiner. A limit of zero or less records all errors, which is the
+// default.
+func (g *Group) MaxRecordedErrors(n int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.maxErrs = n
+}
+
+// DroppedErrors returns the number of member errors which were not recorded
+// during the most recent run because of the limit set by
+// MaxRecordedErrors.
+func (g *Group) DroppedErrors() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.dropped
+}
+
+// DedupeErrors sets whether duplicate errors are collapsed before they are
+// passed to the error combiner, e.g. when many members fail because they
+// lost the same upstream.
+//
+// An error is a duplicate of an earlier error if it matches it with
+// errors.Is or has the same message. Each distinct error is passed to the
+// combiner once, in the order it was first received; if it occurred more
+// than once its message reads like "connection refused (x7)", and it still
+// matches the original error with errors.Is. Errors is not affected. It is
+// disabled by default.
+func (g *Group) DedupeErrors(enabled bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.dedupe = enabled
+}
+
+// countedError is an error which occurred several times during a run.
+type countedError struct {
+	err   error
+	count int
+}
 
-	// Wait for all the members to terminate.
-	for i := terminated; i < cap(errors); i++ {
-		<-errors
+// Error returns the message of the error with the number of occurrences.
+func (e *countedError) Error() string {
+	return fmt.Sprintf("%v (x%d)", e.err, e.count)
+}
+
+// Unwrap returns the error which occurred.
+func (e *countedError) Unwrap() error {
+	return e.err
+}
+
+// dedupe collapses duplicate errors, keeping the first occurrence of each.
+func dedupe(errs []error) []error {
+	var unique []error
+	var counts []int
+next:
+	for _, err := range errs {
+		for i, u := range unique {
+			if errors.Is(err, u) || err.Error() == u.Error() {
+				counts[i]++
+				continue next
+			}
+		}
+		unique = append(unique, err)
+		counts = append(counts, 1)
+	}
+	for i, n := range counts {
+		if n > 1 {
+			unique[i] = &countedError{err: unique[i], count: n}
+		}
 	}
+	return unique
+}
 
+// SetErrorDecorator registers a function which annotates each non-nil error
+// returned by a member routine, e.g. to add member-specific context.
+//
+// The decorator is called with the member's name and its error as soon as
+// the routine returns, and its result is used in place of the original
+// error everywhere: by OnError, Errors, Results and Run. If the decorator
+// returns nil, the member is treated as having returned nil. If no decorator
+// is set, errors are used unchanged.
+func (g *Group) SetErrorDecorator(decorate func(name string, err error) error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.decorate = decorate
+}
+
+// PreferError registers an ordering used to select the error which
+// terminates the Group when several member errors have been collected.
+//
+// When the Group is about to terminate, any results which have already been
+// delivered by other members are collected as well, and the error which
+// sorts first according to less is used to terminate the Group, e.g. the
+// most severe error. Without an ordering, the first error to arrive is used.
+func (g *Group) PreferError(less func(a, b error) bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.prefer = less
+}
+
+// SetSeverity registers a classifier which rates the severity of member
+// errors, where a higher value is more severe.
+//
+// Without an ordering registered with PreferError, the most severe error
+// collected when the Group is about to terminate is used to terminate it,
+// in the same way as with PreferError; if several are equally severe, the
+// first to arrive is used. An ordering registered with PreferError takes
+// precedence over the classifier. Without either, the first error to arrive
+// is used. See also SetSeverityFloor.
+func (g *Group) SetSeverity(classify func(err error) int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.severity = classify
+}
+
+// SetSeverityFloor sets the severity below which member errors do not
+// terminate the Group, e.g. to tolerate warnings but fail on critical
+// errors. It only applies if a classifier is registered with SetSeverity.
+//
+// Errors below the floor are treated like errors from advisory members:
+// they are collected, but do not terminate the Group and are not used as
+// the error which terminates it. Errors from leaders still terminate the
+// Group. By default there is no floor.
+func (g *Group) SetSeverityFloor(floor int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.floor = floor
+	g.hasFloor = true
+}
+
+// less reports whether the error a is preferred over the error b to
+// terminate the Group, using the ordering registered with PreferError or
+// else the classifier registered with SetSeverity.
+func (o *options) less(a, b error) bool {
+	if o.prefer != nil {
+		return o.prefer(a, b)
+	}
+	return o.severity(a) > o.severity(b)
+}
+
+// tolerated reports whether an error is below the severity floor.
+func (o *options) tolerated(err error) bool {
+	return o.severity != nil && o.hasFloor && o.severity(err) < o.floor
+}
+
+// StartBarrier sets whether member routines start behind a barrier.
+//
+// When enabled, Run launches the goroutines for all members but holds them
+// until every goroutine has been spawned, then releases them together. This
+// minimizes the skew between members starting, which is useful for
+// benchmarks and concurrency tests. It is disabled by default.
+func (g *Group) StartBarrier(enabled bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.barrier = enabled
+}
+
+// RecoverPanics sets whether panics in member routines are recovered.
+//
+// When enabled, a panic in a member routine is recovered and reported as a
+// *PanicError returned by that member, unless a panic formatter is set. The
+// error is handled the same as any other error returned by the member, so a
+// panic in an advisory member is recorded without terminating the Group.
+// Panics in terminate functions are recovered in the same way and reported
+// by CleanupErrors, and the remaining members are still terminated. It is
+// disabled by default.
+func (g *Group) RecoverPanics(enabled bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.recover = enabled
+}
+
+// SetPanicFormatter registers a function which converts a panic recovered
+// from a member routine into the error reported for the member, e.g. to fit
+// an existing error taxonomy. It receives the member's name, the recovered
+// value and the stack trace of the panicking goroutine.
+//
+// It is only used when panic recovery is enabled. If unset, recovered panics
+// are reported as a *PanicError.
+func (g *Group) SetPanicFormatter(format func(name string, recovered interface{}, stack []byte) error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.formatPanic = format
+}
+
+// Use registers a middleware which wraps the routine of each member when the
+// Group is run, e.g. to time or trace all members without changing how they
+// are added. The middleware receives the member's name and its routine, and
+// returns the routine to call in its place.
+//
+// Middlewares registered by multiple calls to Use are composed in order, so
+// the first one registered is the outermost. They wrap the routines of
+// members added with AddCtx as well, with the context already bound, and
+// panics in them are recovered along with those in the routines if
+// RecoverPanics is enabled.
+func (g *Group) Use(mw func(name string, routine func() error) func() error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.middleware = append(g.middleware[:len(g.middleware):len(g.middleware)], mw)
+}
+
+// ReportCompletion sets whether Run reports a clean completion with an error
+// rather than nil, so callers can tell that the Group actually did work.
+//
+// When enabled, a run of a Group with members which finishes cleanly, with
+// every member routine returning nil, returns ErrAllCompleted, and a run of a
+// Group with no members to run returns ErrNoMembers. A run terminated by
+// Stop, or which completes before all members return, e.g. once the quorum
+// set by StopAfterN is reached or a leader returns, still returns nil. It is
+// disabled by default.
+func (g *Group) ReportCompletion(enabled bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.completion = enabled
+}
+
+// StopAfterN sets the number of member routines which must return nil for
+// the Group to terminate cleanly, e.g. to wait for any 2 of 3 redundant
+// requests to succeed.
+//
+// Once n routines have returned nil, all members are terminated and Run
+// returns nil; the contexts of members added with AddCtx are canceled with
+// ErrHedgeLost as the cause before their terminate functions are called.
+// Errors from member routines do not terminate the Group unless they make it
+// impossible for n routines to succeed, in which case the Group terminates
+// and Run returns a *MultiError holding all of the errors returned by member
+// routines, including those returned while terminating. An error combiner
+// takes precedence as usual, and if an error preference or severity is set,
+// the preferred error is returned instead. A value of zero or less disables
+// the quorum, which is the default.
+func (g *Group) StopAfterN(n int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.quorum = n
+}
+
+// StopOnFirstNil sets whether the Group terminates cleanly as soon as any
+// member routine returns nil, e.g. to wait for the fastest of several
+// redundant initializers.
+//
+// When enabled, errors from member routines do not terminate the Group, and
+// the first routine to return nil terminates the Group with Run returning
+// nil. If every routine returns an error, Run returns a *MultiError holding
+// all of them. An error combiner takes precedence as usual, and if an error
+// preference or severity is set, the preferred error is returned instead.
+// This is the same as StopAfterN(1); setting both is a configuration error
+// which Run and Validate report as ErrInvalidConfig. It is disabled by
+// default.
+func (g *Group) StopOnFirstNil(enabled bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.firstNil = enabled
+}
+
+// SkipNilRoutines sets whether members without a routine are skipped when
+// the Group is run, rather than failing the run with ErrNilRoutine. This is
+// useful for config-driven groups where some members are optionally
+// defined.
+//
+// Each skipped member is reported to the Group's logger; see SetLogger. It
+// is disabled by default.
+func (g *Group) SkipNilRoutines(enabled bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.skipNil = enabled
+}
+
+// NoTerminateOnCleanCompletion sets whether terminate functions are skipped
+// when every member routine returns nil on its own, since there is nothing
+// left for them to stop.
+//
+// Terminate functions are still called if the run is terminated by an
+// error, by Stop, or by reaching a quorum while other routines are running.
+// The OnTerminated handler is called for every member either way. It is
+// disabled by default.
+func (g *Group) NoTerminateOnCleanCompletion(enabled bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.skipClean = enabled
+}
+
+// IdempotentTerminate sets whether the terminate function of each member is
+// called at most once per run, so it does not need to guard against being
+// called again, e.g. by closing a channel only once.
+//
+// Without it, a member's terminate function may be called several times in
+// a run, e.g. once when its timeout expires and again when the Group
+// terminates. When enabled, only the first call is made, with the error
+// given to that call. The routine of a member added with AddScheduled is
+// called once per window, so its terminate function may be called once per
+// window instead. This also applies to streamed members run by RunStream.
+// It is disabled by default.
+func (g *Group) IdempotentTerminate(enabled bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.idempotent = enabled
+}
+
+// DebugDetectStuckTerminate sets how long a member's routine may take to
+// return after its terminate function is called before the Group reports it
+// as stuck, e.g. to catch a terminate function which does not cause its
+// routine to return during development.
+//
+// A stuck member is reported to the Group's logger, see SetLogger, along with
+// the stacks of all goroutines so the routine can be found. The member is not
+// otherwise affected. A duration of zero or less disables detection, which
+// is the default.
+func (g *Group) DebugDetectStuckTerminate(d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.stuck = d
+}
+
+// defaultAbortGrace is how long the routine of a member which is terminated
+// early is waited for if no grace period is set with SetAbortGrace.
+const defaultAbortGrace = 5 * time.Second
+
+// SetAbortGrace sets how long the Group waits for the routine of a member
+// to return after terminating it early, because it exceeded its timeout or
+// deadline or failed its health checks.
+//
+// If the routine has not returned within the grace period, as measured by
+// the Group's clock, the member is treated as having returned its timeout
+// or health check error anyway and the routine is abandoned: it keeps
+// running in the background, and whatever it returns is ignored. A duration
+// of zero or less uses the default of five seconds.
+func (g *Group) SetAbortGrace(d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.grace = d
+}
+
+// detectStuck reports the member if its routine has not returned within the
+// duration set by DebugDetectStuckTerminate of its terminate function being
+// called.
+func (g *Group) detectStuck(m *member, st *state) {
+	d := g.stuck
+	if d <= 0 {
+		return
+	}
+	timer := g.clock().NewTimer(d)
+	go func() {
+		defer timer.Stop()
+		select {
+		case <-st.done:
+		case <-timer.C():
+			buf := make([]byte, 1<<20)
+			buf = buf[:runtime.Stack(buf, true)]
+			g.log().Printf("errgroup: member %q has not returned %v after being terminated\n%s", m.name, d, buf)
+		}
+	}()
+}
+
+// quorumSize returns the number of member routines which must return nil
+// for the Group to terminate cleanly, or zero if there is no quorum.
+func (o *options) quorumSize() int {
+	if o.firstNil {
+		return 1
+	}
+	return o.quorum
+}
+
+// SetValue associates val with key on the Group, e.g. to share a logger or
+// configuration with hooks and members without global variables. As with
+// context values, key should be of an unexported type defined by the
+// package setting it, to avoid collisions. A nil val removes the key.
+//
+// It is safe to call SetValue and Value while the Group is running.
+func (g *Group) SetValue(key, val interface{}) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if val == nil {
+		delete(g.values, key)
+		return
+	}
+	if g.values == nil {
+		g.values = make(map[interface{}]interface{})
+	}
+	g.values[key] = val
+}
+
+// Value returns the value associated with key by SetValue, or nil if there
+// is none.
+func (g *Group) Value(key interface{}) interface{} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.values[key]
+}
+
+// String summarizes the state of the Group: the number and names of its
+// members, whether it is running, and the error which terminated its most
+// recent run. Unnamed members are listed as <unnamed>.
+//
+// It is safe to call String while the Group is running.
+func (g *Group) String() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	names := make([]string, len(g.members))
+	for i, m := range g.members {
+		names[i] = m.name
+		if m.name == "" {
+			names[i] = "<unnamed>"
+		}
+	}
+	return fmt.Sprintf("Group{members: %d %v, running: %t, err: %v}", len(g.members), names, g.running, g.err)
+}
+
+// StopMember stops the named member of a running Group without terminating
+// the rest of the Group.
+//
+// The member's terminate function is called with a nil error, and StopMember
+// waits for its routine to return. The member is then removed from the
+// running Group: its result does not trigger termination and it is not
+// terminated again when the Group terminates. If the Group is not running
+// or the member has already finished, StopMember does nothing.
+func (g *Group) StopMember(name string) {
+	g.mu.Lock()
+	var m *member
+	var st *state
+	for _, candidate := range g.members {
+		if s, ok := g.states[candidate]; ok && candidate.name == name {
+			m, st = candidate, s
+			break
+		}
+	}
+	g.mu.Unlock()
+
+	if st != nil {
+		g.stopMember(m, st)
+	}
+}
+
+// stopMember stops a single member of a running Group, unless it is already
+// terminating or its routine has already returned.
+func (g *Group) stopMember(m *member, st *state) {
+	g.mu.Lock()
+	if st.terminating {
+		g.mu.Unlock()
+		return
+	}
+	select {
+	case <-st.done:
+		g.mu.Unlock()
+		return
+	default:
+	}
+	st.terminating = true
+	st.stopped = true
+	g.mu.Unlock()
+
+	st.cancel(nil)
+	g.terminate(m, st, nil)
+	g.detectStuck(m, st)
+	<-st.done
+	g.terminated(m, nil)
+}
+
+// SetMembers replaces all members of the Group with the given actors in a
+// single operation, so the Group is never observed partially configured.
+//
+// The Group's members may only be replaced while it is not running. It
+// returns whether the members were replaced; while the Group is running,
+// SetMembers has no effect and returns false.
+func (g *Group) SetMembers(actors []Actor) bool {
+	members := make([]*member, len(actors))
+	for i, a := range actors {
+		members[i] = &member{name: a.Name, routine: a.Routine, terminate: a.Terminate}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.running {
+		return false
+	}
+	g.members = members
+	return true
+}
+
+// StopReason returns the reason the most recent run of the Group stopped.
+// It is ReasonNone until a run has finished.
+func (g *Group) StopReason() Reason {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.reason
+}
+
+// WinningMember returns the name of the member whose routine returning nil
+// reached the quorum set by StopAfterN or StopOnFirstNil and terminated the
+// most recent run, e.g. to record which replica answered first. It is empty
+// if the run was not terminated by reaching the quorum, or the member has no
+// name.
+func (g *Group) WinningMember() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.winner
+}
+
+// LastError returns the error returned by the most recent run of the Group,
+// e.g. to log why the previous iteration of a supervision loop stopped. It
+// is reset when a new run starts, so it is nil while the Group is running.
+func (g *Group) LastError() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.err
+}
+
+// AddActorsErr adds the given actors as new members of the Group, e.g. from
+// user-supplied configuration.
+//
+// The actors are checked before any are added: if an actor has no routine or
+// no terminate function, or its name is already used by another actor or
+// member, an error naming the actor is returned and no members are added.
+// Actors without a name are not checked for duplicates.
+func (g *Group) AddActorsErr(actors ...Actor) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	names := make(map[string]bool, len(g.members)+len(actors))
+	for _, m := range g.members {
+		names[m.name] = true
+	}
+	members := make([]*member, len(actors))
+	for i, a := range actors {
+		m := &member{name: a.Name, routine: a.Routine, terminate: a.Terminate}
+		switch {
+		case a.Routine == nil:
+			return fmt.Errorf("%w: actor %s", ErrNilRoutine, m.label(i))
+		case a.Terminate == nil:
+			return fmt.Errorf("%w: actor %s", ErrNilTerminate, m.label(i))
+		case a.Name != "" && names[a.Name]:
+			return fmt.Errorf("%w: actor %s", ErrDuplicateMember, m.label(i))
+		}
+		names[a.Name] = true
+		members[i] = m
+	}
+	g.members = append(g.members, members...)
+	return nil
+}
+
+// IsRunning reports whether the Group is currently running. It is true from
+// the moment Run starts launching members until Run returns.
+func (g *Group) IsRunning() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.running
+}
+
+// GoroutineCount returns the number of goroutines a run of the Group is
+// expected to start with its current configuration, e.g. to check it against
+// a process-wide goroutine budget before launch.
+//
+// The count includes a goroutine for each member, one more for each worker
+// of members added with AddWorkers, and the helper goroutines which track
+// startup, enforce timeouts, deadlines and health checks, stop scoped
+// members, detect stuck terminate functions, watch the idle timeout and
+// complete the run in the background if ReturnOnError is enabled. Members
+// are counted whether or not their condition enables them. Member
+// goroutines run on the pool set by SetPool are not counted, and neither are
+// goroutines started by member routines themselves, including those started
+// with Spawn.
+func (g *Group) GoroutineCount() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var n int
+	for _, m := range g.members {
+		if g.skipNil && m.routine == nil && m.ctxRoutine == nil {
+			continue
+		}
+		if n == 0 {
+			// All member routines are tracked by a single startup goroutine.
+			n++
+		}
+		if g.pool == nil {
+			n++
+		}
+		if m.workers > 1 {
+			n += m.workers
+		}
+		if m.timeout > 0 || !m.deadline.IsZero() || m.health != nil {
+			n++
+		}
+		if m.scope != nil {
+			n++
+		}
+		if g.stuck > 0 {
+			n++
+		}
+	}
+	if n > 0 && g.idle > 0 {
+		// The idle timeout is watched by a single goroutine.
+		n++
+	}
+	if g.detach {
+		// The run is completed in the background.
+		n++
+	}
+	return n
+}
+
+// Progress returns the fraction of the member routines of the current run
+// which have returned, from 0 to 1, e.g. to drive a progress bar for a
+// Group of batch jobs. It is 0 before the Group is first run, and 1 once a
+// run has finished.
+//
+// It is safe to call Progress while the Group is running.
+func (g *Group) Progress() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.total == 0 {
+		if !g.running && g.reason != ReasonNone {
+			return 1
+		}
+		return 0
+	}
+	return float64(g.exited) / float64(g.total)
+}
+
+// Results returns a channel on which the result of each member's routine is
+// sent as it returns during the next run, or the current run if the Group is
+// running. The channel is closed when the run finishes.
+//
+// The channel is buffered to hold a result for every member of the Group at
+// the time Results is called. Results are never allowed to block the run: if
+// the buffer is full, a result is dropped. Calling Results again before the
+// run finishes returns the same channel.
+func (g *Group) Results() <-chan MemberResult {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.results == nil {
+		g.results = make(chan MemberResult, len(g.members))
+	}
+	return g.results
+}
+
+// publish the result of a member's routine to the Results channel, if any,
+// and record it with how long the routine ran for Report.
+func (g *Group) publish(m *member, err error, took time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.timings = append(g.timings, MemberReport{Name: m.name, Labels: m.labels, Err: err, Duration: took})
+	g.send(lifecycleEvent{Event: "finished", Member: m.name, Error: errorString(err)})
+	g.deliver(m, err)
+	if g.results == nil {
+		return
+	}
+	select {
+	case g.results <- MemberResult{Name: m.name, Err: err}:
+	default:
+	}
+}
+
+// deliver sends the result of a member's routine to the channel given to
+// RunWith, if any, without blocking. g.mu must be held.
+func (g *Group) deliver(m *member, err error) {
+	if g.sink == nil {
+		return
+	}
+	select {
+	case g.sink <- MemberResult{Name: m.name, Err: err}:
+	default:
+	}
+}
+
+// WaitStarted blocks until the routines of all members of the Group have
+// started executing in the next run, or the current run if the Group is
+// running. If the most recent run has already started all of its members,
+// WaitStarted returns immediately.
+//
+// This is useful in tests to ensure members are running before injecting
+// a failure, without relying on sleeps.
+func (g *Group) WaitStarted() {
+	g.mu.Lock()
+	started := g.startedChan()
+	g.mu.Unlock()
+	<-started
+}
+
+// startedChan returns the channel closed once all members of a run have
+// started, creating it if needed. g.mu must be held.
+func (g *Group) startedChan() chan struct{} {
+	if g.started == nil {
+		g.started = make(chan struct{})
+	}
+	return g.started
+}
+
+// Done returns a channel which is closed when the current run of the Group
+// finishes, or the next run if the Group is not running, including after a
+// run has finished. Once the channel is closed, LastError returns the error
+// from the run.
+func (g *Group) Done() <-chan struct{} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.doneChan()
+}
+
+// doneChan returns the channel closed once a run has finished, creating it
+// if needed. g.mu must be held.
+func (g *Group) doneChan() chan struct{} {
+	if g.done == nil {
+		g.done = make(chan struct{})
+	}
+	return g.done
+}
+
+// Errors returns the non-nil errors returned by member routines during the
+// most recent run, in the order they were received. This includes errors
+// from advisory members, the error which triggered termination, and errors
+// returned by routines while the Group was terminating, including when it
+// was terminated by Stop.
+func (g *Group) Errors() []error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	errs := make([]error, len(g.errs))
+	copy(errs, g.errs)
+	return errs
+}
+
+// record a member error for the current run.
+func (g *Group) record(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.maxErrs > 0 && len(g.errs) >= g.maxErrs {
+		g.dropped++
+		return
+	}
+	g.errs = append(g.errs, err)
+}
+
+// retain the error which triggered termination among the recorded errors,
+// replacing the most recently recorded error if it was dropped.
+func (g *Group) retain(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.dropped == 0 {
+		return
+	}
+	for _, e := range g.errs {
+		if e == err {
+			return
+		}
+	}
+	g.errs[len(g.errs)-1] = err
+}
+
+// Run the routines of all Group members concurrently.
+//
+// If a routine terminates with a nil error, the other members will continue
+// to run. When the first non-nil error is returned from a member routine, all
+// members of the Group will be terminated. This function does not return until
+// all members have terminated, unless ReturnOnError is enabled. Once all
+// members terminate, this will return the error which triggered the group
+// termination.
+//
+// Note that if a member routine returns a nil error, its terminate function
+// will not be called until a non-nil error is returned by another member of
+// the group.
+//
+// A Group may be run again once Run returns, but it may not be run
+// concurrently; if the Group is already running, ErrRunning is returned.
+// Before running any members, Run checks the configuration of the Group
+// as Validate does, and returns the first problem found.
+func (g *Group) Run() error {
+	l, err := g.begin()
+	if err != nil {
+		return err
+	}
+	if g.detach {
+		return g.detached(l)
+	}
+	return g.complete(l)
+}
+
+// ReturnOnError sets whether Run returns as soon as the error which
+// triggers termination is known, rather than once all members have
+// terminated, e.g. for latency-sensitive callers.
+//
+// When enabled, the members are terminated in the background after Run
+// returns, so the resources they hold may still be being released. The
+// Group keeps running until they have terminated: Done is closed once they
+// have, and the Group cannot be run again before then. The error returned
+// by Run is the one which triggered termination, without the error
+// combiner or error mapper applied; LastError returns the final error once
+// Done is closed. A run which is stopped or completes cleanly still returns
+// once all members have terminated. It only applies to Run, and is disabled
+// by default.
+func (g *Group) ReturnOnError(enabled bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.detach = enabled
+}
+
+// detached completes a run prepared by begin in the background, returning
+// the error which triggered termination as soon as it is known.
+func (g *Group) detached(l *launch) error {
+	l.early = make(chan error, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- g.complete(l)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case err := <-l.early:
+		select {
+		case final := <-done:
+			return final
+		default:
+			return err
+		}
+	}
+}
+
+// RunWithCallback runs the Group in the background and calls onDone with
+// the error Run would return once the run finishes. It returns immediately.
+//
+// The Group is marked as running before RunWithCallback returns, so the
+// protection against concurrent runs applies: if the Group is already
+// running, onDone is called with ErrRunning.
+func (g *Group) RunWithCallback(onDone func(err error)) {
+	l, err := g.begin()
+	go func() {
+		if err == nil {
+			err = g.complete(l)
+		}
+		onDone(err)
+	}()
+}
+
+// RunWith runs the Group like Run, and additionally sends the result of each
+// member's routine on results as it returns, e.g. to handle member
+// completions in a select loop alongside other event sources.
+//
+// Results are never allowed to block the run: if results is not ready to
+// receive, the result is dropped, so results should be buffered or
+// serviced promptly. The channel is not closed by the Group. Unlike the
+// channel returned by Results, it only receives the results of this run.
+func (g *Group) RunWith(results chan<- MemberResult) error {
+	l, err := g.begin()
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	g.sink = results
+	g.mu.Unlock()
+
+	// The sink is cleared by complete before the Group stops running.
+	return g.complete(l)
+}
+
+// launch holds what is needed to run a Group once it has been marked as
+// running.
+type launch struct {
+	members []*member
+	started chan struct{}
+	stop    chan struct{}
+
+	// early, if set, receives the error which triggers termination as soon
+	// as it is known.
+	early chan error
+}
+
+// begin marks the Group as running and prepares a new run, returning
+// ErrRunning if the Group is already running.
+func (g *Group) begin() (*launch, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.running {
+		return nil, ErrRunning
+	}
+	g.prune()
+	if err := g.validate(); err != nil {
+		return nil, err
+	}
+	g.running = true
+	g.err = nil
+	g.errs = nil
+	g.dropped = 0
+	g.cleanup = nil
+	g.total = 0
+	g.exited = 0
+	g.all = false
+	g.order = nil
+	g.reason = ReasonNone
+	g.winner = ""
+	g.began = g.clock().Now()
+	g.elapsed = 0
+	g.timings = nil
+	select {
+	case <-g.startedChan():
+		// The previous run has started; this run needs a new channel.
+		g.started = make(chan struct{})
+	default:
+	}
+	g.doneChan()
+	g.stop = make(chan struct{})
+	return &launch{members: g.runnable(), started: g.started, stop: g.stop}, nil
+}
+
+// runnable returns the members of the Group to launch, leaving out members
+// without a routine if SkipNilRoutines is set. g.mu must be held.
+func (g *Group) runnable() []*member {
+	if !g.skipNil {
+		return g.members
+	}
+	members := make([]*member, 0, len(g.members))
+	for i, m := range g.members {
+		if m.routine == nil && m.ctxRoutine == nil {
+			g.log().Printf("errgroup: skipping %s: no routine", m.label(i))
+			continue
+		}
+		members = append(members, m)
+	}
+	return members
+}
+
+// complete a run prepared by begin, returning its error once it finishes.
+func (g *Group) complete(l *launch) error {
+	members := enabled(l.members)
+	reason, err := g.run(members, l.started, l.stop, l.early)
+
+	// If an error combiner is specified and there is an error,
+	// combine all of the collected errors into the returned error.
+	// Otherwise, aggregate them if enabled and there are several.
+	if err != nil && (g.combine != nil || g.aggregate) {
+		errs := g.Errors()
+		if g.dedupe {
+			errs = dedupe(errs)
+		}
+		if g.combine == nil {
+			// The error which triggered termination comes first, followed
+			// by the other routine errors and then the cleanup errors.
+			errs = append(leading(err, errs), g.CleanupErrors()...)
+		}
+		switch {
+		case g.combine != nil:
+			err = g.combine(errs)
+		case len(errs) > 1:
+			err = &MultiError{Errors: errs}
+		}
+	}
+
+	// If completion is reported, distinguish a clean completion, in which
+	// every member returned, from a run without members. A run which
+	// completed early, e.g. once its quorum was reached or its leader
+	// returned, is not reported.
+	if reason == ReasonCompleted && g.completion {
+		switch {
+		case g.total == 0:
+			err = ErrNoMembers
+		case g.all:
+			err = ErrAllCompleted
+		}
+	}
+
+	// If an error mapper is specified, it has the final say on the
+	// returned error.
+	if g.mapErr != nil {
+		err = g.mapErr(err)
+	}
+
+	g.mu.Lock()
+	// The "done" event is logged before the Group stops running, so it is
+	// always the last event of its run. Likewise, the event log and results
+	// sink are detached so that they do not receive anything from a later
+	// run.
+	g.send(lifecycleEvent{Event: "done", Error: errorString(err), Reason: reason.String()})
+	g.events = nil
+	g.sink = nil
+	g.running = false
+	g.err = err
+	g.reason = reason
+	g.elapsed = g.clock().Now().Sub(g.began)
+	if g.results != nil {
+		close(g.results)
+		g.results = nil
+	}
+	// Once the run has finished, Done refers to the next run.
+	close(g.done)
+	g.done = nil
+	g.mu.Unlock()
+	return err
+}
+
+// Stop terminates the running Group.
+//
+// All members are terminated with a nil error, and Run returns nil once they
+// have terminated. Stop does not wait for the members to terminate. If
+// RunWithRetry is running the Group, it also stops retrying, including while
+// it waits between runs. Otherwise, if the Group is not running, Stop does
+// nothing.
+func (g *Group) Stop() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.retry != nil {
+		select {
+		case <-g.retry:
+		default:
+			close(g.retry)
+		}
+	}
+	if !g.running {
+		return
+	}
+	g.halt(g.stop)
+}
+
+// halt stops the run whose stop channel is given, if it has not already
+// been stopped. Unlike Stop, it has no effect on a later run. g.mu must be
+// held.
+func (g *Group) halt(stop chan struct{}) {
+	select {
+	case <-stop:
+	default:
+		close(stop)
+	}
+}
+
+// Serve runs the Group in the background and returns once the routines of
+// all of its members have started, e.g. to start a server group in a test.
+//
+// The returned stop function stops the Group as with Stop and returns the
+// error from the run once it has finished; calling it again returns the same
+// error. If the Group could not be run, e.g. because it is already running
+// or is misconfigured, Serve returns that error and a nil stop function. If
+// the run finishes before all members have started, Serve returns the error
+// from the run along with the stop function.
+func (g *Group) Serve() (stop func() error, err error) {
+	l, err := g.begin()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(chan error, 1)
+	go func() {
+		res <- g.complete(l)
+	}()
+
+	var once sync.Once
+	var runErr error
+	stop = func() error {
+		once.Do(func() {
+			g.Stop()
+			runErr = <-res
+		})
+		return runErr
+	}
+
+	select {
+	case <-l.started:
+		return stop, nil
+	case err := <-res:
+		once.Do(func() {
+			runErr = err
+		})
+		return stop, err
+	}
+}
+
+// Restart terminates the running Group and runs it again with its current
+// members, which may have been reconfigured in the meantime, returning the
+// error from the new run.
+//
+// The Run call for the original run returns nil once its members have
+// terminated. If the Group is not running, Restart just runs it.
+func (g *Group) Restart() error {
+	g.mu.Lock()
+	running, done := g.running, g.done
+	g.mu.Unlock()
+
+	if running {
+		g.Stop()
+		<-done
+	}
+	return g.Run()
+}
+
+// RunWithRetry runs the Group and, if the run is terminated by a member
+// error, runs it again from scratch, up to attempts runs in total. It
+// returns the error from the last run.
+//
+// Before each retry, RunWithRetry sleeps for backoff(n), where n is the
+// number of runs which have failed so far; a nil backoff retries
+// immediately. If the error from the run wraps an error created by
+// RetryAfter, its delay is used instead of the backoff. It stops retrying as
+// soon as a run returns nil or ErrAllCompleted, e.g. because it completed
+// cleanly, went idle or had its error mapped to nil, and does not retry if
+// the Group could not be run, e.g. because it is already running or is
+// misconfigured. Calling Stop aborts RunWithRetry, both during a run and
+// while it waits between runs; the error from the last run is returned.
+func (g *Group) RunWithRetry(attempts int, backoff func(attempt int) time.Duration) error {
+	abort := make(chan struct{})
+	defer func() {
+		g.mu.Lock()
+		if g.retry == abort {
+			g.retry = nil
+		}
+		g.mu.Unlock()
+	}()
+
+	var err error
+	for n := 1; ; n++ {
+		l, berr := g.begin()
+		if berr != nil {
+			return berr
+		}
+		if n == 1 {
+			// Only a call which is running the Group can be aborted by
+			// Stop; a call which failed to begin must not take over the
+			// abort channel of another call.
+			g.mu.Lock()
+			if g.retry == nil {
+				g.retry = abort
+			}
+			g.mu.Unlock()
+		}
+		err = g.complete(l)
+		if err == nil || errors.Is(err, ErrAllCompleted) || n >= attempts {
+			return err
+		}
+		select {
+		case <-abort:
+			return err
+		default:
+		}
+		var delay time.Duration
+		var retry *RetryAfterError
+		switch {
+		case errors.As(err, &retry):
+			delay = retry.Delay
+		case backoff != nil:
+			delay = backoff(n)
+		}
+		select {
+		case <-g.clock().After(delay):
+		case <-abort:
+			return err
+		}
+	}
+}
+
+// RetryAfterError is returned by a member routine to request that the Group
+// is only run again after a delay; see RetryAfter.
+type RetryAfterError struct {
+	// Delay is how long to wait before running the Group again.
+	Delay time.Duration
+}
+
+// Error returns the error message, including the requested delay.
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("errgroup: retry after %v", e.Delay)
+}
+
+// RetryAfter returns an error which a member routine can return to have
+// RunWithRetry wait for d before the next attempt, rather than using its
+// backoff, e.g. until a rate limit reported by an upstream resets.
+//
+// The delay applies to running the whole Group again, as RunWithRetry does;
+// members are never restarted individually. Outside of RunWithRetry, the
+// error is treated like any other member error.
+func RetryAfter(d time.Duration) error {
+	return &RetryAfterError{Delay: d}
+}
+
+// Spawn runs fn in a new goroutine as part of the running Group, so members
+// can hand off work to helper goroutines without losing track of them.
+//
+// An error returned by fn is handled like an error returned by a member
+// routine: it is recorded and terminates the Group. Run does not return
+// until all spawned goroutines have returned, so fn should return once ctx
+// is done, where ctx is typically the context given to a member added with
+// AddCtx, which is canceled when the Group terminates.
+//
+// If the Group is not running, has finished terminating its members, or ctx
+// is already done, fn is not run.
+func (g *Group) Spawn(ctx context.Context, fn func() error) {
+	g.mu.Lock()
+	sp := g.spawner
+	if sp == nil || ctx.Err() != nil {
+		g.mu.Unlock()
+		return
+	}
+	sp.wg.Add(1)
+	g.mu.Unlock()
+
+	go func() {
+		defer sp.wg.Done()
+		if err := fn(); err != nil {
+			g.record(err)
+			select {
+			case sp.failed <- err:
+			default:
+			}
+		}
+	}()
+}
+
+// RunOnce runs the Group at most once.
+//
+// The first call to RunOnce runs the Group as Run does. Every later call
+// returns the error from that first run without running the members again.
+// This differs from Run, which may be called repeatedly to run the members
+// again each time. RunOnce and Run should not be mixed on the same Group.
+func (g *Group) RunOnce() error {
+	g.once.Do(func() {
+		g.onceErr = g.Run()
+	})
+	return g.onceErr
+}
+
+// remove a member from the Group.
+func (g *Group) remove(m *member) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i, candidate := range g.members {
+		if candidate == m {
+			g.members = append(g.members[:i:i], g.members[i+1:]...)
+			return
+		}
+	}
+}
+
+// prune removes members whose scope is done from the Group. g.mu must be
+// held.
+func (g *Group) prune() {
+	members := g.members[:0:0]
+	for _, m := range g.members {
+		if m.scope == nil || m.scope.Err() == nil {
+			members = append(members, m)
+		}
+	}
+	g.members = members
+}
+
+// enabled returns the members which are enabled for a run.
+func enabled(members []*member) []*member {
+	active := make([]*member, 0, len(members))
+	for _, m := range members {
+		if m.enabled == nil || m.enabled() {
+			active = append(active, m)
+		}
+	}
+	return active
+}
+
+// call the routine of a member, terminating it early if it does not return
+// within its timeout or fails its health checks.
+func (g *Group) call(ctx context.Context, m *member, st *state) error {
+	if m.timeout <= 0 && m.deadline.IsZero() && m.health == nil {
+		return g.invoke(ctx, m, st)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- g.invoke(ctx, m, st)
+	}()
+
+	clk := g.clock()
+	var timeout <-chan time.Time
+	if m.timeout > 0 {
+		timer := clk.NewTimer(m.timeout)
+		defer timer.Stop()
+		timeout = timer.C()
+	}
+
+	var deadline <-chan time.Time
+	if !m.deadline.IsZero() {
+		timer := clk.NewTimer(m.deadline.Sub(clk.Now()))
+		defer timer.Stop()
+		deadline = timer.C()
+	}
+
+	// The health check timer is restarted after each check.
+	var health Timer
+	var check <-chan time.Time
+	if m.health != nil {
+		health = clk.NewTimer(m.interval)
+		defer func() {
+			health.Stop()
+		}()
+		check = health.C()
+	}
+
+	var failures int
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-timeout:
+			err := fmt.Errorf("%w after %v", ErrMemberTimeout, m.timeout)
+			if m.name != "" {
+				err = fmt.Errorf("%w: %s after %v", ErrMemberTimeout, m.name, m.timeout)
+			}
+			return g.abort(m, st, err, done)
+		case <-deadline:
+			at := m.deadline.Format(time.RFC3339Nano)
+			err := fmt.Errorf("%w at %s", ErrMemberDeadline, at)
+			if m.name != "" {
+				err = fmt.Errorf("%w: %s at %s", ErrMemberDeadline, m.name, at)
+			}
+			return g.abort(m, st, err, done)
+		case <-check:
+			health = clk.NewTimer(m.interval)
+			check = health.C()
+			herr := m.health()
+			if herr == nil {
+				failures = 0
+				continue
+			}
+			if failures++; failures >= m.failures {
+				err := fmt.Errorf("%w: %w", ErrUnhealthy, herr)
+				if m.name != "" {
+					err = fmt.Errorf("%w: %s: %w", ErrUnhealthy, m.name, herr)
+				}
+				return g.abort(m, st, err, done)
+			}
+		}
+	}
+}
+
+// abort a member's routine by calling its terminate function with err,
+// returning err once the routine has returned or the abort grace period has
+// passed, whichever is first; see SetAbortGrace.
+func (g *Group) abort(m *member, st *state, err error, done <-chan error) error {
+	g.terminate(m, st, err)
+
+	grace := g.grace
+	if grace <= 0 {
+		grace = defaultAbortGrace
+	}
+	timer := g.clock().NewTimer(grace)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C():
+		g.log().Printf("errgroup: member %q has not returned %v after being terminated early; abandoning it", m.name, grace)
+	}
+	return err
+}
+
+// terminate calls the terminate function of a member with err, recording
+// the call for TerminateOrder and recovering from a panic if enabled. If
+// IdempotentTerminate is enabled, it is only called once per run, or once
+// per window for a scheduled member; see rearm.
+func (g *Group) terminate(m *member, st *state, err error) {
+	g.mu.Lock()
+	if g.idempotent && st.called {
+		g.mu.Unlock()
+		return
+	}
+	st.called = true
+	if !m.relay {
+		g.order = append(g.order, m.name)
+	}
+	g.mu.Unlock()
+
+	if g.recover {
+		defer func() {
+			if r := recover(); r != nil {
+				g.recordCleanup(g.panicError(m.name, r, debug.Stack()))
+			}
+		}()
+	}
+	m.terminate(err)
+}
+
+// rearm allows the terminate function of a member to be called again before
+// its routine is called again, returning false if the member's context is
+// already done.
+func (g *Group) rearm(ctx context.Context, st *state) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if ctx.Err() != nil {
+		return false
+	}
+	st.called = false
+	return true
+}
+
+// activationKey is the context key for the activation of a member.
+type activationKey struct{}
+
+// activation gives code running within a member's routine, such as the
+// routine of a scheduled member, access to the member's terminate function
+// as called by the Group.
+type activation struct {
+	g   *Group
+	m   *member
+	st  *state
+	ctx context.Context
+}
+
+// terminate calls the member's terminate function as the Group would.
+func (a *activation) terminate(err error) {
+	a.g.terminate(a.m, a.st, err)
+}
+
+// rearm allows the member's terminate function to be called again.
+func (a *activation) rearm() bool {
+	return a.g.rearm(a.ctx, a.st)
+}
+
+// invoke the routine of a member, running a copy of it for each of the
+// member's workers.
+//
+// If any worker returns an error, the member's terminate function is called
+// to stop the remaining workers, and the first error is returned once all
+// of them have returned.
+func (g *Group) invoke(ctx context.Context, m *member, st *state) error {
+	if m.workers <= 1 {
+		return g.execute(ctx, m)
+	}
+
+	errs := make(chan error, m.workers)
+	for i := 0; i < m.workers; i++ {
+		go func() {
+			errs <- g.execute(ctx, m)
+		}()
+	}
+
+	var err error
+	for i := 0; i < m.workers; i++ {
+		if e := <-errs; e != nil && err == nil {
+			err = e
+			g.terminate(m, st, err)
+		}
+	}
+	return err
+}
+
+// submit runs a member's task on the pool set by SetPool, or in a new
+// goroutine if there is no pool.
+func (g *Group) submit(task func()) error {
+	if g.pool == nil {
+		go task()
+		return nil
+	}
+	if err := g.pool(task); err != nil {
+		return fmt.Errorf("%w: %w", ErrPoolExhausted, err)
+	}
+	return nil
+}
+
+// panicError converts a recovered panic into an error.
+func (g *Group) panicError(name string, recovered interface{}, stack []byte) error {
+	if g.formatPanic != nil {
+		return g.formatPanic(name, recovered, stack)
+	}
+	return &PanicError{Name: name, Value: recovered, Stack: stack}
+}
+
+// execute the routine of a member, recovering from a panic if enabled.
+func (g *Group) execute(ctx context.Context, m *member) (err error) {
+	if g.recover {
+		defer func() {
+			if r := recover(); r != nil {
+				err = g.panicError(m.name, r, debug.Stack())
+			}
+		}()
+	}
+	routine := m.routine
+	if m.ctxRoutine != nil {
+		routine = func() error {
+			return m.ctxRoutine(ctx)
+		}
+	}
+	for i := len(g.middleware) - 1; i >= 0; i-- {
+		routine = g.middleware[i](m.name, routine)
+	}
+	return routine()
+}
+
+// leading returns errs with the first error matching err moved to the front,
+// so that the error which triggered termination is listed first.
+func leading(err error, errs []error) []error {
+	for i, e := range errs {
+		if errors.Is(e, err) {
+			ordered := make([]error, 0, len(errs))
+			ordered = append(ordered, e)
+			ordered = append(ordered, errs[:i]...)
+			return append(ordered, errs[i+1:]...)
+		}
+	}
+	return errs
+}
+
+// aggregated returns a *MultiError holding the errors recorded during the
+// run, collapsed if DedupeErrors is enabled, or err if there are fewer than
+// two of them.
+func (g *Group) aggregated(err error) error {
+	errs := g.Errors()
+	if g.dedupe {
+		errs = dedupe(errs)
+	}
+	if len(errs) < 2 {
+		return err
+	}
+	return &MultiError{Errors: errs}
+}
+
+// handleError calls the error handlers, if any, with the error which
+// triggered termination of a run.
+func (g *Group) handleError(ctx context.Context, err error) {
+	if g.onError != nil {
+		g.onError(err)
+	}
+	if g.onErrorCtx != nil {
+		g.onErrorCtx(ctx, err)
+	}
+}
+
+// terminated notifies the OnTerminated handler, if any, that a member has
+// finished terminating.
+func (g *Group) terminated(m *member, err error) {
+	if m.relay {
+		return
+	}
+	g.emit(lifecycleEvent{Event: "terminated", Member: m.name, Error: errorString(err)})
+	if g.onTerminated != nil {
+		g.onTerminated(m.name, err)
+	}
+}
+
+// claim a member for termination, returning false if its terminate
+// function has already been called.
+func (g *Group) claim(st *state) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if st.terminating {
+		return false
+	}
+	st.terminating = true
+	return true
+}
+
+// memberContext returns the context for the routine of a member, derived
+// from base, and the state of the member in the run.
+func (g *Group) memberContext(base context.Context, m *member) (context.Context, *state) {
+	st := &state{done: make(chan struct{})}
+	a := &activation{g: g, m: m, st: st}
+	ctx := context.WithValue(base, loggerKey{}, &memberLogger{name: m.name, logger: g.log()})
+	if m.labels != nil {
+		ctx = context.WithValue(ctx, labelsKey{}, m.labels)
+	}
+	ctx = context.WithValue(ctx, activationKey{}, a)
+	ctx, st.cancel = context.WithCancelCause(ctx)
+	a.ctx = ctx
+	return ctx, st
+}
+
+// perform the routine of a member as part of a run, tracing it and
+// reporting its result, and return its error.
+func (g *Group) perform(ctx context.Context, m *member, st *state) error {
+	if m.relay {
+		// The members run by a relay member are performed individually.
+		return g.call(ctx, m, st)
+	}
+	begun := g.clock().Now()
+	var end func(error)
+	if g.tracer != nil {
+		ctx, end = g.tracer.StartSpan(ctx, m.name)
+	}
+	g.emit(lifecycleEvent{Event: "started", Member: m.name})
+	g.at(hookStart, m)
+	err := g.call(ctx, m, st)
+	g.at(hookReturn, m)
+	if end != nil {
+		end(err)
+	}
+	if err != nil && g.decorate != nil {
+		err = g.decorate(m.name, err)
+	}
+	if err != nil && g.attribute {
+		err = &MemberError{Name: m.name, Err: err}
+	}
+	g.publish(m, err, g.clock().Now().Sub(begun))
+	return err
+}
+
+// exit marks the routine of a member as having exited. Relay members are
+// not counted towards the progress of the run.
+func (g *Group) exit(m *member, st *state) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	close(st.done)
+	if !m.relay {
+		g.exited++
+	}
+}
+
+// stopped reports whether a member was stopped individually.
+func (g *Group) stopped(st *state) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return st.stopped
+}
+
+// run the routines of the given members, returning why the run stopped and
+// the error which triggered termination. The started channel is closed once
+// all member routines have started, and closing the stop channel terminates
+// the run. If early is not nil, the error which triggers termination is also
+// sent on it as soon as it is known.
+func (g *Group) run(members []*member, started, stop chan struct{}, early chan<- error) (Reason, error) {
+	// If there are no members of the group, there is nothing to do.
+	if len(members) == 0 {
+		close(started)
+		return ReasonCompleted, nil
+	}
+
+	// Track when all member routines have started.
+	var starting sync.WaitGroup
+	starting.Add(len(members))
+	go func() {
+		starting.Wait()
+		close(started)
+	}()
+
+	// If a start barrier is used, member routines wait for it to be
+	// released before running.
+	var start chan struct{}
+	if g.barrier {
+		start = make(chan struct{})
+	}
+
+	// Each member gets its own context, canceled on termination.
+	base := g.baseCtx
+	if base == nil {
+		base = context.Background()
+	}
+	if g.limiter != nil {
+		base = context.WithValue(base, limiterKey{}, g.limiter)
+	}
+	var act *activity
+	if g.idle > 0 {
+		act = newActivity()
+		base = context.WithValue(base, activityKey{}, act)
+	}
+	ctxs := make(map[*member]context.Context, len(members))
+	states := make(map[*member]*state, len(members))
+	for _, m := range members {
+		ctxs[m], states[m] = g.memberContext(base, m)
+	}
+	// Goroutines spawned by members feed their errors into the run.
+	var spawns sync.WaitGroup
+	failed := make(chan error, 1)
+
+	g.mu.Lock()
+	g.states = states
+	g.spawner = &spawner{wg: &spawns, failed: failed}
+	g.total = 0
+	for _, m := range members {
+		if !m.relay {
+			g.total++
+		}
+	}
+	g.mu.Unlock()
+	defer func() {
+		g.mu.Lock()
+		g.states = nil
+		g.mu.Unlock()
+	}()
+
+	// Run the goroutine for each member of the group. If the pool cannot
+	// accept a member, it and the remaining members are reported as failed
+	// and the group terminates.
+	results := make(chan result, len(members))
+	mem := newBudget(g.memory)
+	var exhausted error
+	for _, m := range members {
+		ctx, st := ctxs[m], states[m]
+		task := func() {
+			if start != nil {
+				<-start
+			}
+			// A member waiting for memory has not started until it is
+			// admitted, or the group terminates first.
+			admitted := mem.acquire(ctx, m.cost)
+			starting.Done()
+			if !admitted {
+				// The group terminated before the member was admitted.
+				g.exit(m, st)
+				results <- result{m, nil}
+				return
+			}
+			err := g.perform(ctx, m, st)
+			mem.release(m.cost)
+			g.exit(m, st)
+			results <- result{m, err}
+		}
+		if exhausted == nil {
+			exhausted = g.submit(task)
+		}
+		if exhausted != nil {
+			starting.Done()
+			g.exit(m, st)
+			results <- result{m, exhausted}
+		}
+	}
+	if exhausted != nil {
+		select {
+		case failed <- exhausted:
+		default:
+		}
+	}
+
+	// All goroutines have been spawned; release the barrier.
+	if start != nil {
+		close(start)
+	}
+
+	// Stop scoped members once their scope is done.
+	for _, m := range members {
+		if m.scope == nil {
+			continue
+		}
+		go func(m *member, st *state) {
+			select {
+			case <-m.scope.Done():
+				g.remove(m)
+				g.stopMember(m, st)
+			case <-st.done:
+			}
+		}(m, states[m])
+	}
+
+	// Terminate the group if no member reports activity within the idle
+	// timeout.
+	var idle <-chan struct{}
+	if act != nil {
+		quiet := make(chan struct{})
+		defer close(quiet)
+		idle = act.watch(g.clock(), g.idle, quiet)
+	}
+
+	// Wait for the first non-nil error returned by a non-advisory member,
+	// for a leader to return, for the quorum to be reached or become
+	// unreachable if one is set, or for the group to be stopped or go idle.
+	o := &outcome{g: g, states: states, total: len(members), exited: make(map[*member]bool, len(members))}
+	var err error
+	var stopped, idled bool
+wait:
+	for len(o.exited) < len(members) {
+		select {
+		case r := <-results:
+			if terminate, e := o.add(r); terminate {
+				err = e
+				break wait
+			}
+		case e := <-failed:
+			err = e
+			break wait
+		case <-stop:
+			stopped = true
+			break wait
+		case <-idle:
+			stopped, idled = true, true
+			break wait
+		}
+	}
+
+	// If an error preference or severity is specified, collect any other
+	// results which have already been delivered and select the preferred
+	// error.
+	if err != nil && (g.prefer != nil || g.severity != nil) {
+		err = o.prefer(results, err)
+	}
+
+	// If errors were dropped, make sure the error which triggered
+	// termination is still recorded.
+	if err != nil {
+		g.retain(err)
+		if early != nil {
+			early <- err
+		}
+	}
+
+	// If there is an error, execute the error handlers.
+	if err != nil {
+		g.handleError(base, err)
+	}
+
+	// Terminate all group members which have not already been stopped.
+	// Members whose routines have already exited are done terminating
+	// once their terminate function returns. If every routine has returned
+	// cleanly, the terminate functions may be skipped.
+	// Routines still waiting for memory are not admitted once the group
+	// terminates.
+	mem.close()
+
+	// Give the BeforeTerminate function, if any, a chance to prepare for
+	// termination before any member is terminated.
+	clean := err == nil && !stopped && len(o.exited) == len(members)
+	g.mu.Lock()
+	g.all = clean
+	g.mu.Unlock()
+	if g.beforeTerm != nil && (!clean || !g.skipClean) {
+		if e := g.beforeTerm(err); e != nil {
+			g.recordCleanup(e)
+		}
+	}
+
+	// Members which lost the race to reach the quorum have their contexts
+	// canceled with ErrHedgeLost.
+	cause := err
+	if err == nil && !stopped && o.reached {
+		cause = ErrHedgeLost
+		g.mu.Lock()
+		g.winner = o.winner.name
+		g.mu.Unlock()
+	}
+	for _, m := range members {
+		st := states[m]
+		if !g.claim(st) {
+			continue
+		}
+		st.cancel(cause)
+		if !clean || !g.skipClean {
+			g.at(hookTerminate, m)
+			g.terminate(m, st, err)
+			g.detectStuck(m, st)
+		}
+		if o.exited[m] {
+			g.terminated(m, err)
+		}
+	}
+
+	// Wait for all the members to terminate, recording any errors they
+	// return while terminating.
+	for i := len(o.exited); i < len(members); i++ {
+		r := <-results
+		if g.stopped(states[r.member]) {
+			continue
+		}
+		if r.err != nil {
+			g.record(r.err)
+		}
+		g.terminated(r.member, err)
+	}
+
+	// Wait for any goroutines spawned by members. An error from one which
+	// failed after the members terminated is still returned.
+	g.mu.Lock()
+	g.spawner = nil
+	g.mu.Unlock()
+	spawns.Wait()
+	if err == nil && !stopped {
+		select {
+		case err = <-failed:
+			g.handleError(base, err)
+		default:
+		}
+	}
+
+	// Ensure the started channel is closed before the run ends so that a
+	// later run does not reuse it.
+	<-started
+
+	// A run which failed because the quorum became unreachable failed
+	// because of all of the errors, unless one of them is preferred.
+	if err != nil && o.unreachable && g.prefer == nil && g.severity == nil {
+		err = g.aggregated(err)
+	}
+
+	switch {
+	case idled:
+		return ReasonIdle, nil
+	case stopped:
+		return ReasonStopped, nil
+	case errors.Is(err, ErrMemberTimeout), errors.Is(err, ErrMemberDeadline):
+		return ReasonTimeout, err
+	case err != nil:
+		return ReasonMemberError, err
+	}
+	return ReasonCompleted, nil
+}
+
+// outcome accumulates the results of member routines during a run and
+// decides when the run should terminate.
+type outcome struct {
+	g      *Group
+	states map[*member]*state
+	total  int
+
+	// exited holds the members whose results have been received.
+	exited map[*member]bool
+
+	succeeded  int
+	candidates []error
+
+	// reached is set once the quorum has been reached, by the routine of
+	// winner returning nil.
+	reached bool
+	winner  *member
+
+	// unreachable is set once too many routines have failed for the
+	// quorum to be reached.
+	unreachable bool
+}
+
+// add the result of a member routine, returning whether the run should
+// terminate and the error which triggered termination, if any.
+func (o *outcome) add(r result) (bool, error) {
+	g := o.g
+	o.exited[r.member] = true
+
+	// The results of members which were stopped individually do not
+	// affect the run.
+	if g.stopped(o.states[r.member]) {
+		return false, nil
+	}
+
+	// A relay member returning nil only means the members it ran have
+	// returned.
+	if r.err == nil && r.member.relay {
+		return false, nil
+	}
+
+	if r.err == nil {
+		o.succeeded++
+		quorum := g.quorumSize()
+		if quorum > 0 && o.succeeded >= quorum && !o.reached {
+			o.reached = true
+			o.winner = r.member
+		}
+		return r.member.leader || r.member.sentinel || o.reached, nil
+	}
+
+	g.record(r.err)
+	advisory := r.member.advisory || g.tolerated(r.err)
+	if !advisory {
+		o.candidates = append(o.candidates, r.err)
+	}
+	switch {
+	case r.member.leader:
+		return true, r.err
+	case g.quorumSize() > 0:
+		// Errors only terminate the run once the quorum can no longer
+		// be reached.
+		remaining := o.total - len(o.exited)
+		if o.succeeded+remaining < g.quorumSize() {
+			o.unreachable = true
+			return true, r.err
+		}
+	case !advisory:
+		return true, r.err
+	}
+	return false, nil
+}
+
+// prefer collects any results which have already been delivered and
+// returns the preferred error among the candidates collected in the run.
+func (o *outcome) prefer(results <-chan result, err error) error {
+collect:
+	for len(o.exited) < o.total {
+		select {
+		case r := <-results:
+			o.add(r)
+		default:
+			break collect
+		}
+	}
+	for _, e := range o.candidates {
+		if o.g.less(e, err) {
+			err = e
+		}
+	}
 	return err
 }
@@ -1,8 +1,14 @@
 package errgroup
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -40,6 +46,52 @@ func TestGroup_OnError(t *testing.T) {
 	}
 }
 
+func TestGroup_OnErrorCtx(t *testing.T) {
+	type key struct{}
+	var g Group
+	g.WithBaseContext(context.WithValue(context.Background(), key{}, "base"))
+
+	var calledErrHandler, calledCtxHandler bool
+	g.OnError(func(err error) {
+		calledErrHandler = true
+	})
+	g.OnErrorCtx(func(ctx context.Context, err error) {
+		calledCtxHandler = true
+		if v := ctx.Value(key{}); v != "base" {
+			t.Errorf("unexpected context value: %v", v)
+		}
+		if !errors.Is(err, errTest) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+	g.Add(
+		func() error {
+			return errTest
+		},
+		func(err error) {},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	select {
+	case err := <-res:
+		if err != errTest {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !calledErrHandler {
+			t.Error("error handler not called")
+		}
+		if !calledCtxHandler {
+			t.Error("context error handler not called")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
 func TestGroup_RunEmpty(t *testing.T) {
 	var g Group
 
@@ -103,6 +155,97 @@ func TestGroup_RunOneNil(t *testing.T) {
 	}
 }
 
+func TestGroup_IdempotentTerminate(t *testing.T) {
+	var calls int32
+	cancel := make(chan struct{})
+
+	var g Group
+	g.IdempotentTerminate(true)
+	g.AddWithTimeout(
+		func() error {
+			<-cancel
+			return nil
+		},
+		func(e error) {
+			atomic.AddInt32(&calls, 1)
+			close(cancel)
+		},
+		5*time.Millisecond,
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	select {
+	case err := <-res:
+		if !errors.Is(err, ErrMemberTimeout) {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if n := atomic.LoadInt32(&calls); n != 1 {
+			t.Errorf("terminate called %d times, expected 1", n)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_NoTerminateOnCleanCompletion(t *testing.T) {
+	var calledTerminate int32
+	var terminated int32
+
+	var g Group
+	g.NoTerminateOnCleanCompletion(true)
+	g.OnTerminated(func(name string, err error) {
+		atomic.AddInt32(&terminated, 1)
+	})
+	for i := 0; i < 2; i++ {
+		g.Add(
+			func() error {
+				return nil
+			},
+			func(e error) {
+				atomic.AddInt32(&calledTerminate, 1)
+			},
+		)
+	}
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if n := atomic.LoadInt32(&calledTerminate); n != 0 {
+			t.Errorf("terminate called %d times, but not expected", n)
+		}
+		if n := atomic.LoadInt32(&terminated); n != 2 {
+			t.Errorf("terminated handler called %d times, expected 2", n)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+
+	// Terminate functions are still called when a routine fails.
+	g.Add(
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+	if err := g.Run(); err != errTest {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if n := atomic.LoadInt32(&calledTerminate); n != 2 {
+		t.Errorf("terminate called %d times, expected 2", n)
+	}
+}
+
 func TestGroup_RunOneError(t *testing.T) {
 	var calledRoutine bool
 	var calledTerminate bool
@@ -267,10 +410,11 @@ func TestGroup_RunMultipleError(t *testing.T) {
 		},
 	)
 
-	// Third member
+	// Third member, which fails once the other members are running.
+	ready := make(chan struct{})
 	g.Add(
 		func() error {
-			time.Sleep(50 * time.Millisecond)
+			<-ready
 			calledRoutine3 = true
 			return errTest
 		},
@@ -279,6 +423,19 @@ func TestGroup_RunMultipleError(t *testing.T) {
 		},
 	)
 
+	var running int32
+	var order []*member
+	g.hook = func(p hookPoint, m *member) {
+		switch {
+		case p == hookStart && m != g.members[2]:
+			if atomic.AddInt32(&running, 1) == 2 {
+				close(ready)
+			}
+		case p == hookTerminate:
+			order = append(order, m)
+		}
+	}
+
 	res := make(chan error)
 	defer close(res)
 
@@ -309,7 +466,3048 @@ func TestGroup_RunMultipleError(t *testing.T) {
 		if !calledTerminate3 {
 			t.Error("terminate3 not called")
 		}
+		if len(order) != 3 || order[0] != g.members[0] || order[1] != g.members[1] || order[2] != g.members[2] {
+			t.Error("members not terminated in order")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_String(t *testing.T) {
+	var g Group
+	if s := g.String(); s != "Group{members: 0 [], running: false, err: <nil>}" {
+		t.Errorf("unexpected string for empty group: %s", s)
+	}
+
+	started := make(chan struct{})
+	cancel := make(chan struct{})
+	g.AddNamed(
+		"db",
+		func() error {
+			close(started)
+			<-cancel
+			return errTest
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			<-cancel
+			return nil
+		},
+		func(e error) {},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	<-started
+	if s := g.String(); s != "Group{members: 2 [db <unnamed>], running: true, err: <nil>}" {
+		t.Errorf("unexpected string for running group: %s", s)
+	}
+	close(cancel)
+
+	select {
+	case <-res:
+		if s := g.String(); s != "Group{members: 2 [db <unnamed>], running: false, err: test error}" {
+			t.Errorf("unexpected string for finished group: %s", s)
+		}
 	case <-time.After(100 * time.Millisecond):
 		t.Error("test case timeout")
 	}
 }
+
+func TestGroup_AddNamed(t *testing.T) {
+	var g Group
+	g.AddNamed(
+		"test",
+		func() error {
+			return nil
+		},
+		func(e error) {
+
+		},
+	)
+
+	if len(g.members) != 1 {
+		t.Fatal("no members added")
+	}
+	if g.members[0].name != "test" {
+		t.Errorf("unexpected member name: %s", g.members[0].name)
+	}
+}
+
+func TestGroup_Replace(t *testing.T) {
+	var g Group
+	g.AddNamed(
+		"test",
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+
+	if g.Replace("missing", func() error { return nil }, func(e error) {}) {
+		t.Error("replaced missing member, but not expected")
+	}
+	if !g.Replace("test", func() error { return errTest }, func(e error) {}) {
+		t.Error("member not replaced, but expected")
+	}
+
+	if err := g.Run(); err != errTest {
+		t.Errorf("got unexpected error: %v", err)
+	}
+}
+
+func TestGroup_ReplaceCtx(t *testing.T) {
+	var g Group
+	g.AddNamedCtx(
+		"test",
+		func(ctx context.Context) error {
+			return nil
+		},
+		func(e error) {},
+	)
+
+	if !g.Replace("test", func() error { return errTest }, func(e error) {}) {
+		t.Error("member not replaced, but expected")
+	}
+
+	if err := g.Run(); err != errTest {
+		t.Errorf("got unexpected error: %v", err)
+	}
+}
+
+func TestGroup_ReplaceRunning(t *testing.T) {
+	var g Group
+	started := make(chan struct{})
+	cancel := make(chan struct{})
+	g.AddNamed(
+		"test",
+		func() error {
+			close(started)
+			<-cancel
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	<-started
+	if g.Replace("test", func() error { return nil }, func(e error) {}) {
+		t.Error("member replaced while running, but not expected")
+	}
+	close(cancel)
+
+	if err := <-res; err != errTest {
+		t.Errorf("got unexpected error: %v", err)
+	}
+}
+
+func TestGroup_AddAdvisory(t *testing.T) {
+	var calledTerminate bool
+
+	var g Group
+	g.AddAdvisory(
+		func() error {
+			return errTest
+		},
+		func(e error) {
+			calledTerminate = true
+		},
+	)
+
+	if err := g.Run(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if !calledTerminate {
+		t.Error("terminate not called")
+	}
+
+	errs := g.Errors()
+	if len(errs) != 1 || errs[0] != errTest {
+		t.Errorf("unexpected recorded errors: %v", errs)
+	}
+}
+
+func TestGroup_AddBestEffort(t *testing.T) {
+	var g Group
+	g.AddBestEffort(
+		"optional",
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+
+	if err := g.Run(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+
+	errs := g.Errors()
+	if len(errs) != 1 || errs[0] != errTest {
+		t.Errorf("unexpected recorded errors: %v", errs)
+	}
+	for _, m := range g.Report().Members {
+		if m.Name == "optional" && m.Err != errTest {
+			t.Errorf("unexpected member report: %v", m)
+		}
+	}
+}
+
+func TestGroup_AddAdvisoryWithError(t *testing.T) {
+	errAdvisory := errors.New("advisory error")
+	advised := make(chan struct{})
+
+	var g Group
+	g.AddAdvisory(
+		func() error {
+			defer close(advised)
+			return errAdvisory
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			<-advised
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	if err := g.Run(); err != errTest {
+		t.Errorf("got unexpected error: %v", err)
+	}
+
+	errs := g.Errors()
+	if len(errs) != 2 || errs[0] != errAdvisory || errs[1] != errTest {
+		t.Errorf("unexpected recorded errors: %v", errs)
+	}
+}
+
+func TestGroup_RunOnce(t *testing.T) {
+	var calls int
+
+	var g Group
+	g.Add(
+		func() error {
+			calls++
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	for i := 0; i < 3; i++ {
+		if err := g.RunOnce(); err != errTest {
+			t.Errorf("got unexpected error: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("routine called %d times, expected 1", calls)
+	}
+}
+
+func TestGroup_GoroutineCount(t *testing.T) {
+	var g Group
+	if n := g.GoroutineCount(); n != 0 {
+		t.Errorf("got %d goroutines for an empty group, expected 0", n)
+	}
+
+	g.Add(
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+	g.AddWorkers(
+		"workers",
+		3,
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+	g.AddWithTimeout(
+		func() error {
+			return nil
+		},
+		func(e error) {},
+		time.Second,
+	)
+
+	// One for startup, one per member, one per worker and one for the
+	// timeout.
+	if n := g.GoroutineCount(); n != 8 {
+		t.Errorf("got %d goroutines, expected 8", n)
+	}
+}
+
+func TestGroup_IsRunning(t *testing.T) {
+	var g Group
+	if g.IsRunning() {
+		t.Error("group running, but not expected")
+	}
+
+	started := make(chan struct{})
+	cancel := make(chan struct{})
+	g.Add(
+		func() error {
+			close(started)
+			<-cancel
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	<-started
+	if !g.IsRunning() {
+		t.Error("group not running, but expected")
+	}
+	if err := g.Run(); err != ErrRunning {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	close(cancel)
+
+	if err := <-res; err != errTest {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if g.IsRunning() {
+		t.Error("group running, but not expected")
+	}
+}
+
+func TestGroup_Progress(t *testing.T) {
+	var g Group
+	if p := g.Progress(); p != 0 {
+		t.Errorf("unexpected progress before run: %v", p)
+	}
+
+	release := make(chan struct{})
+	g.Add(
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			<-release
+			return nil
+		},
+		func(e error) {},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	timeout := time.After(100 * time.Millisecond)
+	for g.Progress() != 0.5 {
+		select {
+		case <-timeout:
+			t.Fatalf("unexpected progress: %v", g.Progress())
+		case <-time.After(time.Millisecond):
+		}
+	}
+	close(release)
+
+	if err := <-res; err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if p := g.Progress(); p != 1 {
+		t.Errorf("unexpected progress after run: %v", p)
+	}
+}
+
+func TestGroup_SetErrorCombiner(t *testing.T) {
+	errCombined := errors.New("combined error")
+	errAdvisory := errors.New("advisory error")
+	advised := make(chan struct{})
+
+	var g Group
+	g.AddAdvisory(
+		func() error {
+			defer close(advised)
+			return errAdvisory
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			<-advised
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	var combined []error
+	g.SetErrorCombiner(func(errs []error) error {
+		combined = errs
+		return errCombined
+	})
+
+	if err := g.Run(); err != errCombined {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if len(combined) != 2 || combined[0] != errAdvisory || combined[1] != errTest {
+		t.Errorf("unexpected combined errors: %v", combined)
+	}
+}
+
+func TestGroup_MapError(t *testing.T) {
+	errMapped := errors.New("mapped error")
+
+	var g Group
+	var calls int
+	g.MapError(func(err error) error {
+		calls++
+		if err == nil {
+			return errMapped
+		}
+		return nil
+	})
+	g.Add(
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	if err := g.Run(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if err := g.LastError(); err != nil {
+		t.Errorf("got unexpected last error: %v", err)
+	}
+
+	g.SetMembers([]Actor{{
+		Routine: func() error {
+			return nil
+		},
+		Terminate: func(e error) {},
+	}})
+	if err := g.Run(); err != errMapped {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("mapper called %d times, expected 2", calls)
+	}
+}
+
+func TestGroup_MaxRecordedErrors(t *testing.T) {
+	errAdvisory := errors.New("advisory error")
+	advised := make(chan struct{}, 3)
+
+	var g Group
+	g.MaxRecordedErrors(2)
+	for i := 0; i < 3; i++ {
+		g.AddAdvisory(
+			func() error {
+				advised <- struct{}{}
+				return errAdvisory
+			},
+			func(e error) {},
+		)
+	}
+	g.Add(
+		func() error {
+			for i := 0; i < 3; i++ {
+				<-advised
+			}
+			time.Sleep(10 * time.Millisecond)
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	if err := g.Run(); err != errTest {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	errs := g.Errors()
+	if len(errs) != 2 || errs[0] != errAdvisory || errs[1] != errTest {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+	if n := g.DroppedErrors(); n != 2 {
+		t.Errorf("dropped %d errors, expected 2", n)
+	}
+}
+
+func TestGroup_DedupeErrors(t *testing.T) {
+	var g Group
+	g.DedupeErrors(true)
+	for _, err := range []error{errTest, errTest, errors.New("test error")} {
+		err := err
+		g.Add(
+			func() error {
+				return err
+			},
+			func(e error) {},
+		)
+	}
+
+	var combined []error
+	g.SetErrorCombiner(func(errs []error) error {
+		combined = errs
+		return errs[0]
+	})
+
+	err := g.Run()
+	if len(combined) != 1 {
+		t.Fatalf("unexpected combined errors: %v", combined)
+	}
+	if err.Error() != "test error (x3)" {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if errors.Unwrap(err) == nil {
+		t.Error("original error not wrapped")
+	}
+	if errs := g.Errors(); len(errs) != 3 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
+
+func TestGroup_AggregateWhenMultiple(t *testing.T) {
+	errOther := errors.New("other error")
+	cancel := make(chan struct{})
+
+	var g Group
+	g.AggregateWhenMultiple(true)
+	g.Add(
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			<-cancel
+			return errOther
+		},
+		func(e error) {
+			close(cancel)
+		},
+	)
+
+	err := g.Run()
+	var multi *MultiError
+	if !errors.As(err, &multi) || len(multi.Errors) != 2 {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if !errors.Is(err, errTest) || !errors.Is(err, errOther) {
+		t.Errorf("errors not wrapped: %v", err)
+	}
+}
+
+func TestGroup_AggregateWhenMultipleCleanup(t *testing.T) {
+	errOther := errors.New("other error")
+	errCleanup := errors.New("cleanup error")
+	cancel := make(chan struct{})
+
+	var g Group
+	g.AggregateWhenMultiple(true)
+	g.BeforeTerminate(func(cause error) error {
+		return errCleanup
+	})
+	g.Add(
+		func() error {
+			<-cancel
+			return errOther
+		},
+		func(e error) {
+			close(cancel)
+		},
+	)
+	g.Add(
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	// The error which triggered termination comes first, then the other
+	// routine errors and then the cleanup errors.
+	err := g.Run()
+	var multi *MultiError
+	if !errors.As(err, &multi) || len(multi.Errors) != 3 {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if multi.Errors[0] != errTest || multi.Errors[1] != errOther || multi.Errors[2] != errCleanup {
+		t.Errorf("unexpected errors: %v", multi.Errors)
+	}
+	if !errors.Is(err, errTest) || !errors.Is(err, errCleanup) {
+		t.Errorf("errors not wrapped: %v", err)
+	}
+}
+
+func TestGroup_AggregateWhenMultipleSingle(t *testing.T) {
+	var g Group
+	g.AggregateWhenMultiple(true)
+	g.Add(
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+
+	if err := g.Run(); err != errTest {
+		t.Errorf("got unexpected error: %v", err)
+	}
+}
+
+func TestGroup_SetErrorCombinerNoError(t *testing.T) {
+	var calledCombiner bool
+
+	var g Group
+	g.Add(
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+	g.SetErrorCombiner(func(errs []error) error {
+		calledCombiner = true
+		return errTest
+	})
+
+	if err := g.Run(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if calledCombiner {
+		t.Error("combiner called, but not expected")
+	}
+}
+
+func TestGroup_StartBarrier(t *testing.T) {
+	var g Group
+	g.StartBarrier(true)
+
+	// Each member waits for the other to start, which only succeeds
+	// if both are running together.
+	ready1 := make(chan struct{})
+	ready2 := make(chan struct{})
+	g.Add(
+		func() error {
+			close(ready1)
+			<-ready2
+			return nil
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			close(ready2)
+			<-ready1
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	select {
+	case err := <-res:
+		if err != errTest {
+			t.Errorf("got unexpected error: %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_RecoverPanics(t *testing.T) {
+	var g Group
+	g.RecoverPanics(true)
+	g.AddNamed(
+		"test",
+		func() error {
+			panic("test panic")
+		},
+		func(e error) {},
+	)
+
+	err := g.Run()
+	perr, ok := err.(*PanicError)
+	if !ok {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if perr.Name != "test" || perr.Value != "test panic" {
+		t.Errorf("unexpected panic error: %#v", perr)
+	}
+	if len(perr.Stack) == 0 {
+		t.Error("panic error has no stack")
+	}
+}
+
+func TestGroup_RecoverPanicsAdvisory(t *testing.T) {
+	var g Group
+	g.RecoverPanics(true)
+	g.AddAdvisory(
+		func() error {
+			panic("test panic")
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+
+	if err := g.Run(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+
+	errs := g.Errors()
+	if len(errs) != 1 {
+		t.Fatalf("unexpected recorded errors: %v", errs)
+	}
+	if _, ok := errs[0].(*PanicError); !ok {
+		t.Errorf("recorded error is not a panic error: %v", errs[0])
+	}
+}
+
+func TestGroup_BeforeTerminate(t *testing.T) {
+	errCleanup := errors.New("cleanup error")
+	var calledTerminate bool
+	var cause error
+	cancel := make(chan struct{})
+
+	var g Group
+	g.Add(
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			<-cancel
+			return nil
+		},
+		func(e error) {
+			calledTerminate = true
+			close(cancel)
+		},
+	)
+	g.BeforeTerminate(func(err error) error {
+		if calledTerminate {
+			t.Error("terminate called before BeforeTerminate")
+		}
+		cause = err
+		return errCleanup
+	})
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	select {
+	case err := <-res:
+		if err != errTest {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if cause != errTest {
+			t.Errorf("unexpected cause: %v", cause)
+		}
+		if errs := g.CleanupErrors(); len(errs) != 1 || errs[0] != errCleanup {
+			t.Errorf("unexpected cleanup errors: %v", errs)
+		}
+		if errs := g.Errors(); len(errs) != 1 {
+			t.Errorf("unexpected errors: %v", errs)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_ReturnOnError(t *testing.T) {
+	release := make(chan struct{})
+	cancel := make(chan struct{})
+
+	var g Group
+	g.ReturnOnError(true)
+	g.Add(
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			<-cancel
+			return nil
+		},
+		func(e error) {
+			<-release
+			close(cancel)
+		},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	select {
+	case err := <-res:
+		if err != errTest {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if !g.IsRunning() {
+			t.Error("group finished before its members terminated")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("test case timeout")
+	}
+
+	close(release)
+	select {
+	case <-g.Done():
+		if err := g.LastError(); err != errTest {
+			t.Errorf("got unexpected last error: %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_TerminateOrder(t *testing.T) {
+	var g Group
+	for _, name := range []string{"a", "b", "c"} {
+		g.AddNamed(
+			name,
+			func() error {
+				return nil
+			},
+			func(e error) {},
+		)
+	}
+
+	if err := g.Run(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if order := strings.Join(g.TerminateOrder(), ","); order != "a,b,c" {
+		t.Errorf("unexpected terminate order: %s", order)
+	}
+}
+
+func TestGroup_OnTerminated(t *testing.T) {
+	cancel := make(chan struct{})
+
+	var g Group
+	g.AddNamed(
+		"one",
+		func() error {
+			<-cancel
+			return nil
+		},
+		func(e error) {
+			close(cancel)
+		},
+	)
+	g.AddNamed(
+		"two",
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	terminated := map[string]error{}
+	g.OnTerminated(func(name string, err error) {
+		terminated[name] = err
+	})
+
+	if err := g.Run(); err != errTest {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if len(terminated) != 2 {
+		t.Fatalf("unexpected terminated members: %v", terminated)
+	}
+	for _, name := range []string{"one", "two"} {
+		if err, ok := terminated[name]; !ok || err != errTest {
+			t.Errorf("member %s: unexpected termination: %v, %v", name, err, ok)
+		}
+	}
+}
+
+func TestGroup_AddCtx(t *testing.T) {
+	var g Group
+	g.AddCtx(
+		func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	select {
+	case err := <-res:
+		if err != errTest {
+			t.Errorf("got unexpected error: %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_WithBaseContext(t *testing.T) {
+	type key struct{}
+
+	var g Group
+	g.WithBaseContext(context.WithValue(context.Background(), key{}, "value"))
+
+	var value interface{}
+	g.AddCtx(
+		func(ctx context.Context) error {
+			value = ctx.Value(key{})
+			<-ctx.Done()
+			return nil
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	if err := g.Run(); err != errTest {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if value != "value" {
+		t.Errorf("unexpected context value: %v", value)
+	}
+}
+
+func TestGroup_StopMember(t *testing.T) {
+	var calledTerminate1 int
+	var calledTerminate2 int
+
+	started := make(chan struct{})
+	cancel1 := make(chan struct{})
+	cancel2 := make(chan struct{})
+
+	var g Group
+	g.AddNamed(
+		"one",
+		func() error {
+			close(started)
+			<-cancel1
+			return errTest
+		},
+		func(e error) {
+			calledTerminate1++
+			close(cancel1)
+		},
+	)
+	g.AddNamed(
+		"two",
+		func() error {
+			<-cancel2
+			return nil
+		},
+		func(e error) {
+			calledTerminate2++
+			close(cancel2)
+		},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	<-started
+	g.StopMember("one")
+	if calledTerminate1 != 1 {
+		t.Errorf("terminate1 called %d times, expected 1", calledTerminate1)
+	}
+	if !g.IsRunning() {
+		t.Error("group not running, but expected")
+	}
+
+	// Stopping a member which has already stopped does nothing.
+	g.StopMember("one")
+
+	g.StopMember("two")
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if calledTerminate1 != 1 {
+			t.Errorf("terminate1 called %d times, expected 1", calledTerminate1)
+		}
+		if calledTerminate2 != 1 {
+			t.Errorf("terminate2 called %d times, expected 1", calledTerminate2)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_StopAfterN(t *testing.T) {
+	cancel := make(chan struct{})
+	var calledTerminate bool
+
+	var g Group
+	g.StopAfterN(2)
+	g.Add(
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			<-cancel
+			return nil
+		},
+		func(e error) {
+			calledTerminate = true
+			close(cancel)
+		},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if !calledTerminate {
+			t.Error("terminate not called")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_StopAfterNUnreachable(t *testing.T) {
+	errOther := errors.New("other error")
+	failed := make(chan struct{})
+
+	var g Group
+	g.StopAfterN(2)
+	g.Add(
+		func() error {
+			defer close(failed)
+			return errOther
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			<-failed
+			return errTest
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+
+	err := g.Run()
+	var multi *MultiError
+	if !errors.As(err, &multi) || len(multi.Errors) != 2 {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if !errors.Is(err, errTest) || !errors.Is(err, errOther) {
+		t.Errorf("errors not aggregated: %v", err)
+	}
+	if errs := g.Errors(); len(errs) != 2 {
+		t.Errorf("unexpected recorded errors: %v", errs)
+	}
+}
+
+func TestGroup_PreferError(t *testing.T) {
+	errSevere := errors.New("severe error")
+	failed := make(chan struct{})
+
+	var g Group
+	g.StopAfterN(2)
+	g.Add(
+		func() error {
+			defer close(failed)
+			return errSevere
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			<-failed
+			return errTest
+		},
+		func(e error) {},
+	)
+	g.PreferError(func(a, b error) bool {
+		return a == errSevere
+	})
+
+	var handled error
+	g.OnError(func(err error) {
+		handled = err
+	})
+
+	if err := g.Run(); err != errSevere {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if handled != errSevere {
+		t.Errorf("error handler got unexpected error: %v", handled)
+	}
+}
+
+func TestGroup_SetSeverity(t *testing.T) {
+	errSevere := errors.New("severe error")
+	warned := make(chan struct{})
+	cancel := make(chan struct{})
+
+	var g Group
+	g.SetSeverity(func(err error) int {
+		if err == errSevere {
+			return 2
+		}
+		return 0
+	})
+	g.SetSeverityFloor(1)
+	g.Add(
+		func() error {
+			defer close(warned)
+			return errTest
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			<-warned
+			return errSevere
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			<-cancel
+			return nil
+		},
+		func(e error) {
+			close(cancel)
+		},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	select {
+	case err := <-res:
+		if err != errSevere {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if errs := g.Errors(); len(errs) != 2 {
+			t.Errorf("unexpected errors: %v", errs)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+
+	// Without an ordering, the most severe error is preferred.
+	if !g.less(errSevere, errTest) || g.less(errTest, errSevere) {
+		t.Error("most severe error not preferred")
+	}
+}
+
+func TestGroup_AddCtxCause(t *testing.T) {
+	var cause error
+
+	var g Group
+	g.AddCtx(
+		func(ctx context.Context) error {
+			<-ctx.Done()
+			cause = context.Cause(ctx)
+			return nil
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	if err := g.Run(); err != errTest {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if cause != errTest {
+		t.Errorf("got unexpected cause: %v", cause)
+	}
+}
+
+func TestGroup_StopOnFirstNilCause(t *testing.T) {
+	var cause error
+
+	var g Group
+	g.StopOnFirstNil(true)
+	g.AddCtx(
+		func(ctx context.Context) error {
+			<-ctx.Done()
+			cause = context.Cause(ctx)
+			return nil
+		},
+		func(e error) {},
+	)
+	g.AddNamed(
+		"winner",
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+
+	if err := g.Run(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if cause != ErrHedgeLost {
+		t.Errorf("got unexpected cause: %v", cause)
+	}
+	if name := g.WinningMember(); name != "winner" {
+		t.Errorf("unexpected winning member: %q", name)
+	}
+}
+
+func TestGroup_AddConditional(t *testing.T) {
+	var calledRoutine bool
+	var calledTerminate bool
+
+	var g Group
+	g.AddConditional(
+		"disabled",
+		func() bool {
+			return false
+		},
+		func() error {
+			calledRoutine = true
+			return errTest
+		},
+		func(e error) {
+			calledTerminate = true
+		},
+	)
+	g.AddConditional(
+		"enabled",
+		func() bool {
+			return true
+		},
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+
+	if err := g.Run(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if calledRoutine {
+		t.Error("routine called, but not expected")
+	}
+	if calledTerminate {
+		t.Error("terminate called, but not expected")
+	}
+}
+
+func TestGroup_Results(t *testing.T) {
+	failed := make(chan struct{})
+
+	var g Group
+	g.AddNamed(
+		"one",
+		func() error {
+			defer close(failed)
+			return nil
+		},
+		func(e error) {},
+	)
+	g.AddNamed(
+		"two",
+		func() error {
+			<-failed
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	results := g.Results()
+	if err := g.Run(); err != errTest {
+		t.Errorf("got unexpected error: %v", err)
+	}
+
+	var got []MemberResult
+	for r := range results {
+		got = append(got, r)
+	}
+	expected := []MemberResult{{"one", nil}, {"two", errTest}}
+	if len(got) != len(expected) {
+		t.Fatalf("unexpected results: %v", got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("unexpected result %d: %v", i, got[i])
+		}
+	}
+}
+
+func TestGroup_RunWith(t *testing.T) {
+	var g Group
+	g.AddNamed(
+		"one",
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+	g.AddNamed(
+		"two",
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	results := make(chan MemberResult, 2)
+	if err := g.RunWith(results); err != errTest {
+		t.Errorf("got unexpected error: %v", err)
+	}
+
+	got := map[string]error{}
+	for i := 0; i < 2; i++ {
+		select {
+		case r := <-results:
+			got[r.Name] = r.Err
+		default:
+			t.Fatalf("missing results: %v", got)
+		}
+	}
+	if len(got) != 2 || got["one"] != nil || got["two"] != errTest {
+		t.Errorf("unexpected results: %v", got)
+	}
+
+	// The channel belongs to the caller and is not closed.
+	select {
+	case r, ok := <-results:
+		t.Errorf("unexpected receive: %v, %v", r, ok)
+	default:
+	}
+}
+
+func TestGroup_AddWithTimeout(t *testing.T) {
+	var calledTerminate bool
+	cancel := make(chan struct{})
+
+	var g Group
+	g.AddWithTimeout(
+		func() error {
+			<-cancel
+			return nil
+		},
+		func(e error) {
+			if !calledTerminate {
+				calledTerminate = true
+				close(cancel)
+			}
+		},
+		10*time.Millisecond,
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	select {
+	case err := <-res:
+		if !errors.Is(err, ErrMemberTimeout) {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if !calledTerminate {
+			t.Error("terminate not called")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_SetAbortGrace(t *testing.T) {
+	var buf bytes.Buffer
+	release := make(chan struct{})
+	defer close(release)
+
+	var g Group
+	g.SetLogger(log.New(&buf, "", 0))
+	g.SetAbortGrace(10 * time.Millisecond)
+	g.AddWithTimeout(
+		func() error {
+			<-release
+			return nil
+		},
+		func(e error) {},
+		10*time.Millisecond,
+	)
+
+	// The member ignores its terminate function, so it is abandoned once
+	// the grace period passes.
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	select {
+	case err := <-res:
+		if !errors.Is(err, ErrMemberTimeout) {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if !strings.Contains(buf.String(), "abandoning") {
+			t.Errorf("unexpected log output: %q", buf.String())
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_SetMembers(t *testing.T) {
+	var g Group
+	g.AddNamed(
+		"old",
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	replaced := g.SetMembers([]Actor{
+		{
+			Name:      "one",
+			Routine:   func() error { return nil },
+			Terminate: func(e error) {},
+		},
+		{
+			Name:      "two",
+			Routine:   func() error { return nil },
+			Terminate: func(e error) {},
+		},
+	})
+
+	if !replaced || len(g.members) != 2 || g.members[0].name != "one" || g.members[1].name != "two" {
+		t.Fatal("members not replaced")
+	}
+	if err := g.Run(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+}
+
+func TestGroup_SetMembersRunning(t *testing.T) {
+	cancel := make(chan struct{})
+
+	var g Group
+	g.AddNamed(
+		"old",
+		func() error {
+			<-cancel
+			return nil
+		},
+		func(e error) {
+			close(cancel)
+		},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	g.WaitStarted()
+	if g.SetMembers(nil) {
+		t.Error("members replaced while running, but not expected")
+	}
+	if len(g.members) != 1 {
+		t.Error("members changed while running")
+	}
+	g.Stop()
+
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_AddLeader(t *testing.T) {
+	var calledTerminate bool
+	cancel := make(chan struct{})
+
+	var g Group
+	g.AddLeader(
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			<-cancel
+			return nil
+		},
+		func(e error) {
+			calledTerminate = true
+			close(cancel)
+		},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if !calledTerminate {
+			t.Error("terminate not called")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_AddSentinel(t *testing.T) {
+	var calledTerminate bool
+	cancel := make(chan struct{})
+
+	var g Group
+	g.Add(
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+	g.AddSentinel(
+		func() error {
+			time.Sleep(5 * time.Millisecond)
+			return nil
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			<-cancel
+			return nil
+		},
+		func(e error) {
+			calledTerminate = true
+			close(cancel)
+		},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if !calledTerminate {
+			t.Error("terminate not called")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_StopReason(t *testing.T) {
+	var g Group
+	if r := g.StopReason(); r != ReasonNone {
+		t.Errorf("unexpected stop reason: %v", r)
+	}
+
+	g.Add(
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+	if err := g.Run(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if r := g.StopReason(); r != ReasonCompleted {
+		t.Errorf("unexpected stop reason: %v", r)
+	}
+
+	g.Add(
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+	if err := g.Run(); err != errTest {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if r := g.StopReason(); r != ReasonMemberError {
+		t.Errorf("unexpected stop reason: %v", r)
+	}
+}
+
+func TestGroup_LastError(t *testing.T) {
+	var g Group
+	if err := g.LastError(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+
+	cancel := make(chan struct{})
+	g.Add(
+		func() error {
+			<-cancel
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	g.WaitStarted()
+	if err := g.LastError(); err != nil {
+		t.Errorf("got unexpected error while running: %v", err)
+	}
+	close(cancel)
+
+	select {
+	case err := <-res:
+		if err != errTest {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if err := g.LastError(); err != errTest {
+			t.Errorf("got unexpected last error: %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_SetErrorDecorator(t *testing.T) {
+	var g Group
+	g.AddNamed(
+		"test",
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+	g.SetErrorDecorator(func(name string, err error) error {
+		return fmt.Errorf("%s: %w", name, err)
+	})
+
+	var handled error
+	g.OnError(func(err error) {
+		handled = err
+	})
+
+	err := g.Run()
+	if err == nil || err.Error() != "test: test error" || !errors.Is(err, errTest) {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if handled != err {
+		t.Errorf("error handler got unexpected error: %v", handled)
+	}
+	if errs := g.Errors(); len(errs) != 1 || errs[0] != err {
+		t.Errorf("unexpected recorded errors: %v", errs)
+	}
+}
+
+func TestGroup_SetAttribution(t *testing.T) {
+	var g Group
+	g.SetAttribution(true)
+	g.AddNamed(
+		"test",
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	err := g.Run()
+	var merr *MemberError
+	if !errors.As(err, &merr) || merr.Name != "test" {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if !errors.Is(err, errTest) {
+		t.Errorf("error does not match the original error: %v", err)
+	}
+	if err.Error() != "test: test error" {
+		t.Errorf("unexpected error message: %q", err.Error())
+	}
+}
+
+func TestGroup_SetErrorDecoratorNil(t *testing.T) {
+	var g Group
+	g.Add(
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+	g.SetErrorDecorator(func(name string, err error) error {
+		return nil
+	})
+
+	if err := g.Run(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+}
+
+func TestGroup_WaitStarted(t *testing.T) {
+	var g Group
+	cancel := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		g.Add(
+			func() error {
+				<-cancel
+				return nil
+			},
+			func(e error) {},
+		)
+	}
+	g.Add(
+		func() error {
+			<-cancel
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	g.WaitStarted()
+	close(cancel)
+
+	select {
+	case err := <-res:
+		if err != errTest {
+			t.Errorf("got unexpected error: %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+
+	// The run has already started, so this returns immediately.
+	g.WaitStarted()
+}
+
+func TestGroup_Stop(t *testing.T) {
+	var terminateErr = errTest
+	cancel := make(chan struct{})
+
+	var g Group
+	g.Add(
+		func() error {
+			<-cancel
+			return nil
+		},
+		func(e error) {
+			terminateErr = e
+			close(cancel)
+		},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	g.WaitStarted()
+	g.Stop()
+
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if terminateErr != nil {
+			t.Errorf("terminate got unexpected error: %v", terminateErr)
+		}
+		if r := g.StopReason(); r != ReasonStopped {
+			t.Errorf("unexpected stop reason: %v", r)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_Serve(t *testing.T) {
+	cancel := make(chan struct{})
+
+	var g Group
+	g.Add(
+		func() error {
+			<-cancel
+			return nil
+		},
+		func(e error) {
+			close(cancel)
+		},
+	)
+
+	stop, err := g.Serve()
+	if err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if !g.IsRunning() {
+		t.Error("group not running")
+	}
+
+	res := make(chan error)
+	go func() {
+		res <- stop()
+	}()
+
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if err := stop(); err != nil {
+			t.Errorf("got unexpected error from second stop: %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_ServeInvalid(t *testing.T) {
+	var g Group
+	g.Add(nil, func(e error) {})
+
+	stop, err := g.Serve()
+	if !errors.Is(err, ErrNilRoutine) {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if stop != nil {
+		t.Error("got unexpected stop function")
+	}
+}
+
+func TestGroup_Restart(t *testing.T) {
+	var runs int32
+
+	var g Group
+	g.AddCtx(
+		func(ctx context.Context) error {
+			if atomic.AddInt32(&runs, 1) > 1 {
+				return errTest
+			}
+			<-ctx.Done()
+			return nil
+		},
+		func(e error) {},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	g.WaitStarted()
+	if err := g.Restart(); err != errTest {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if err := <-res; err != nil {
+		t.Errorf("got unexpected error from original run: %v", err)
+	}
+	if n := atomic.LoadInt32(&runs); n != 2 {
+		t.Errorf("routine run %d times, expected 2", n)
+	}
+}
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans map[string]error
+}
+
+type testSpanKey struct{}
+
+func (t *testTracer) StartSpan(ctx context.Context, name string) (context.Context, func(error)) {
+	return context.WithValue(ctx, testSpanKey{}, name), func(err error) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.spans[name] = err
+	}
+}
+
+func TestGroup_SetTracer(t *testing.T) {
+	tracer := &testTracer{spans: map[string]error{}}
+
+	var g Group
+	g.SetTracer(tracer)
+
+	var span interface{}
+	g.AddNamed(
+		"one",
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+	g.AddCtx(
+		func(ctx context.Context) error {
+			span = ctx.Value(testSpanKey{})
+			return nil
+		},
+		func(e error) {},
+	)
+
+	if err := g.Run(); err != errTest {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if span != "" {
+		t.Errorf("routine got unexpected span: %v", span)
+	}
+	if len(tracer.spans) != 2 || tracer.spans["one"] != errTest {
+		t.Errorf("unexpected spans: %v", tracer.spans)
+	}
+}
+
+type labelTracer struct {
+	labels map[string]string
+}
+
+func (t *labelTracer) StartSpan(ctx context.Context, name string) (context.Context, func(error)) {
+	t.labels = MemberLabels(ctx)
+	return ctx, func(err error) {}
+}
+
+func TestGroup_AddLabeled(t *testing.T) {
+	tracer := &labelTracer{}
+	labels := map[string]string{"tier": "web"}
+
+	var g Group
+	g.SetTracer(tracer)
+	g.AddLabeled(
+		"one",
+		labels,
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+	labels["tier"] = "changed"
+
+	if err := g.Run(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if tracer.labels["tier"] != "web" {
+		t.Errorf("tracer got unexpected labels: %v", tracer.labels)
+	}
+	if r := g.Report(); len(r.Members) != 1 || r.Members[0].Labels["tier"] != "web" {
+		t.Errorf("unexpected report: %v", r.Members)
+	}
+}
+
+func TestGroup_SetValue(t *testing.T) {
+	type key struct{}
+
+	var g Group
+	if v := g.Value(key{}); v != nil {
+		t.Errorf("unexpected value: %v", v)
+	}
+
+	g.SetValue(key{}, "value")
+	g.Add(
+		func() error {
+			if v := g.Value(key{}); v != "value" {
+				t.Errorf("routine got unexpected value: %v", v)
+			}
+			return nil
+		},
+		func(e error) {},
+	)
+	if err := g.Run(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+
+	g.SetValue(key{}, nil)
+	if v := g.Value(key{}); v != nil {
+		t.Errorf("unexpected value after removal: %v", v)
+	}
+}
+
+func TestGroup_Preallocate(t *testing.T) {
+	var g Group
+	g.Add(
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+	g.Preallocate(10)
+
+	if len(g.members) != 1 {
+		t.Errorf("unexpected number of members: %d", len(g.members))
+	}
+	if cap(g.members) != 10 {
+		t.Errorf("unexpected member capacity: %d", cap(g.members))
+	}
+}
+
+func benchmarkGroupAdd(b *testing.B, preallocate bool) {
+	const n = 1000
+	for i := 0; i < b.N; i++ {
+		var g Group
+		if preallocate {
+			g.Preallocate(n)
+		}
+		for j := 0; j < n; j++ {
+			g.Add(
+				func() error {
+					return nil
+				},
+				func(e error) {},
+			)
+		}
+	}
+}
+
+func BenchmarkGroup_Add(b *testing.B) {
+	b.ReportAllocs()
+	benchmarkGroupAdd(b, false)
+}
+
+func BenchmarkGroup_AddPreallocated(b *testing.B) {
+	b.ReportAllocs()
+	benchmarkGroupAdd(b, true)
+}
+
+func TestGroup_AddWatched(t *testing.T) {
+	errUnhealthy := errors.New("unhealthy")
+	cancel := make(chan struct{})
+	var checks int32
+
+	var g Group
+	g.AddWatched(
+		"test",
+		func() error {
+			<-cancel
+			return nil
+		},
+		func() error {
+			// The second check succeeds, resetting the failure count,
+			// so the member fails on the fourth check.
+			n := atomic.AddInt32(&checks, 1)
+			if n == 2 {
+				return nil
+			}
+			return errUnhealthy
+		},
+		func(e error) {
+			select {
+			case <-cancel:
+			default:
+				close(cancel)
+			}
+		},
+		time.Millisecond,
+		2,
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	select {
+	case err := <-res:
+		if !errors.Is(err, ErrUnhealthy) || !errors.Is(err, errUnhealthy) {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if n := atomic.LoadInt32(&checks); n != 4 {
+			t.Errorf("health checked %d times, expected 4", n)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_ErrorsWhileTerminating(t *testing.T) {
+	errShutdown := errors.New("shutdown error")
+	cancel := make(chan struct{})
+
+	var g Group
+	g.Add(
+		func() error {
+			<-cancel
+			return errShutdown
+		},
+		func(e error) {
+			close(cancel)
+		},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	g.WaitStarted()
+	g.Stop()
+
+	if err := <-res; err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if errs := g.Errors(); len(errs) != 1 || errs[0] != errShutdown {
+		t.Errorf("unexpected recorded errors: %v", errs)
+	}
+}
+
+func TestGroup_AddScoped(t *testing.T) {
+	var calledTerminate bool
+	cancel := make(chan struct{})
+	stopped := make(chan struct{})
+
+	ctx, cancelScope := context.WithCancel(context.Background())
+
+	var g Group
+	g.AddScoped(
+		ctx,
+		"scoped",
+		func() error {
+			defer close(stopped)
+			<-cancel
+			return errTest
+		},
+		func(e error) {
+			calledTerminate = true
+			close(cancel)
+		},
+	)
+	g.Add(
+		func() error {
+			<-stopped
+			return nil
+		},
+		func(e error) {},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	g.WaitStarted()
+	cancelScope()
+
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if !calledTerminate {
+			t.Error("terminate not called")
+		}
+		if len(g.members) != 1 {
+			t.Errorf("scoped member not removed")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_AddWorkers(t *testing.T) {
+	var started int32
+	var calledTerminate int32
+	cancel := make(chan struct{})
+
+	var g Group
+	g.AddWorkers(
+		"workers",
+		3,
+		func() error {
+			if atomic.AddInt32(&started, 1) == 3 {
+				return errTest
+			}
+			<-cancel
+			return nil
+		},
+		func(e error) {
+			if atomic.AddInt32(&calledTerminate, 1) == 1 {
+				close(cancel)
+			}
+		},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	select {
+	case err := <-res:
+		if err != errTest {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if n := atomic.LoadInt32(&started); n != 3 {
+			t.Errorf("%d workers started, expected 3", n)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_ReportCompletion(t *testing.T) {
+	var g Group
+	g.ReportCompletion(true)
+
+	if err := g.Run(); err != ErrNoMembers {
+		t.Errorf("got unexpected error: %v", err)
+	}
+
+	g.Add(
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+	if err := g.Run(); err != ErrAllCompleted {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if r := g.StopReason(); r != ReasonCompleted {
+		t.Errorf("unexpected stop reason: %v", r)
+	}
+}
+
+func TestGroup_ReportCompletionEarly(t *testing.T) {
+	for _, leader := range []bool{false, true} {
+		var g Group
+		g.ReportCompletion(true)
+		if leader {
+			g.AddLeader(
+				func() error {
+					return nil
+				},
+				func(e error) {},
+			)
+		} else {
+			g.StopAfterN(1)
+			g.Add(
+				func() error {
+					return nil
+				},
+				func(e error) {},
+			)
+		}
+		cancel := make(chan struct{})
+		g.Add(
+			func() error {
+				<-cancel
+				return nil
+			},
+			func(e error) {
+				close(cancel)
+			},
+		)
+
+		// The run completes before every member returns, so completion
+		// is not reported.
+		res := make(chan error)
+		go func() {
+			res <- g.Run()
+		}()
+
+		select {
+		case err := <-res:
+			if err != nil {
+				t.Errorf("got unexpected error: %v", err)
+			}
+			if r := g.StopReason(); r != ReasonCompleted {
+				t.Errorf("unexpected stop reason: %v", r)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Error("test case timeout")
+		}
+	}
+}
+
+func TestGroup_SetPanicFormatter(t *testing.T) {
+	var g Group
+	g.RecoverPanics(true)
+	g.SetPanicFormatter(func(name string, recovered interface{}, stack []byte) error {
+		return fmt.Errorf("%s: %v", name, recovered)
+	})
+	g.AddNamed(
+		"test",
+		func() error {
+			panic("test panic")
+		},
+		func(e error) {},
+	)
+
+	err := g.Run()
+	if err == nil || err.Error() != "test: test panic" {
+		t.Errorf("got unexpected error: %v", err)
+	}
+}
+
+func TestGroup_Use(t *testing.T) {
+	var calls []string
+	trace := func(label string) func(name string, routine func() error) func() error {
+		return func(name string, routine func() error) func() error {
+			return func() error {
+				calls = append(calls, label+" "+name)
+				err := routine()
+				calls = append(calls, label+" done")
+				return err
+			}
+		}
+	}
+
+	var g Group
+	g.Use(trace("outer"))
+	g.Use(trace("inner"))
+	g.AddNamedCtx(
+		"test",
+		func(ctx context.Context) error {
+			calls = append(calls, "routine")
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	if err := g.Run(); err != errTest {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	expected := "outer test,inner test,routine,inner done,outer done"
+	if s := strings.Join(calls, ","); s != expected {
+		t.Errorf("unexpected calls: %s", s)
+	}
+}
+
+func TestGroup_Clone(t *testing.T) {
+	var g Group
+	g.StopAfterN(1)
+	g.AddNamed(
+		"test",
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+
+	c := g.Clone()
+	if c.quorum != 1 {
+		t.Errorf("options not cloned")
+	}
+	if len(c.members) != 1 || c.members[0] == g.members[0] {
+		t.Fatal("members not cloned")
+	}
+
+	// Replacing a member of the clone does not affect the original.
+	c.Replace("test", func() error { return errTest }, func(e error) {})
+	if err := c.Run(); err != errTest {
+		t.Errorf("got unexpected error from clone: %v", err)
+	}
+	if err := g.Run(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	cancel := make(chan struct{})
+
+	var a Group
+	a.RecoverPanics(true)
+	a.AddNamed(
+		"a",
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	var b Group
+	b.ReportCompletion(true)
+	b.AddNamed(
+		"b",
+		func() error {
+			<-cancel
+			return nil
+		},
+		func(e error) {
+			close(cancel)
+		},
+	)
+
+	m := Merge(&a, &b)
+	if !m.recover || !m.completion {
+		t.Errorf("options not merged")
+	}
+	if len(m.members) != 2 || m.members[0] == a.members[0] || m.members[1] == b.members[0] {
+		t.Fatal("members not merged")
+	}
+
+	res := make(chan error)
+	go func() {
+		res <- m.Run()
+	}()
+
+	select {
+	case err := <-res:
+		if err != errTest {
+			t.Errorf("got unexpected error: %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_StopOnFirstNil(t *testing.T) {
+	cancel := make(chan struct{})
+	failed := make(chan struct{})
+
+	var g Group
+	g.StopOnFirstNil(true)
+	g.Add(
+		func() error {
+			defer close(failed)
+			return errTest
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			<-failed
+			return nil
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			<-cancel
+			return nil
+		},
+		func(e error) {
+			close(cancel)
+		},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_StopOnFirstNilAllFail(t *testing.T) {
+	errFirst := errors.New("first error")
+	failed := make(chan struct{})
+
+	var g Group
+	g.StopOnFirstNil(true)
+	g.Add(
+		func() error {
+			defer close(failed)
+			return errFirst
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			<-failed
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	err := g.Run()
+	var multi *MultiError
+	if !errors.As(err, &multi) || len(multi.Errors) != 2 {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if !errors.Is(err, errFirst) || !errors.Is(err, errTest) {
+		t.Errorf("errors not aggregated: %v", err)
+	}
+}
+
+func TestGroup_RunWithRetry(t *testing.T) {
+	var calls int
+	var backoffs []int
+
+	var g Group
+	g.Add(
+		func() error {
+			calls++
+			if calls < 3 {
+				return errTest
+			}
+			return nil
+		},
+		func(e error) {},
+	)
+
+	backoff := func(attempt int) time.Duration {
+		backoffs = append(backoffs, attempt)
+		return time.Millisecond
+	}
+	if err := g.RunWithRetry(5, backoff); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("routine called %d times, expected 3", calls)
+	}
+	if len(backoffs) != 2 || backoffs[0] != 1 || backoffs[1] != 2 {
+		t.Errorf("unexpected backoffs: %v", backoffs)
+	}
+
+	// The error from the last run is returned once attempts run out.
+	calls = 0
+	if err := g.RunWithRetry(2, nil); err != errTest {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("routine called %d times, expected 2", calls)
+	}
+}
+
+func TestGroup_RunWithRetryMapErrorNil(t *testing.T) {
+	var calls int
+
+	var g Group
+	g.MapError(func(err error) error {
+		return nil
+	})
+	g.Add(
+		func() error {
+			calls++
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	// A run whose error is mapped to nil is not retried.
+	if err := g.RunWithRetry(3, nil); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("routine called %d times, expected 1", calls)
+	}
+}
+
+func TestGroup_AddWaitFor(t *testing.T) {
+	cancel := make(chan struct{})
+
+	var other Group
+	other.Add(
+		func() error {
+			<-cancel
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	var g Group
+	g.AddWaitFor("other", &other)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+	go other.Run()
+
+	g.WaitStarted()
+	other.WaitStarted()
+	close(cancel)
+
+	select {
+	case err := <-res:
+		if err != errTest {
+			t.Errorf("got unexpected error: %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_AddWaitForRerun(t *testing.T) {
+	errOther := errors.New("other error")
+	cancel := make(chan struct{})
+	var runs int
+
+	var other Group
+	other.Add(
+		func() error {
+			if runs++; runs == 1 {
+				return errTest
+			}
+			<-cancel
+			return errOther
+		},
+		func(e error) {},
+	)
+	if err := other.Run(); err != errTest {
+		t.Fatalf("got unexpected error from other: %v", err)
+	}
+
+	var g Group
+	g.AddWaitFor("other", &other)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	// The finished run of other is not waited for.
+	select {
+	case err := <-res:
+		t.Fatalf("returned for a finished run: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	go other.Run()
+	other.WaitStarted()
+	close(cancel)
+
+	select {
+	case err := <-res:
+		if err != errOther {
+			t.Errorf("got unexpected error: %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_AddWaitForStop(t *testing.T) {
+	var other Group
+	other.AddCtx(
+		func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		},
+		func(e error) {},
+	)
+
+	var g Group
+	g.AddWaitFor("other", &other)
+	g.Add(
+		func() error {
+			other.WaitStarted()
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- other.Run()
+	}()
+
+	if err := g.Run(); err != errTest {
+		t.Errorf("got unexpected error: %v", err)
+	}
+
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("got unexpected error from other: %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_DebugDetectStuckTerminate(t *testing.T) {
+	var buf bytes.Buffer
+	var mu sync.Mutex
+	logged := make(chan struct{})
+	cancel := make(chan struct{})
+
+	var g Group
+	g.SetLogger(log.New(writerFunc(func(p []byte) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if buf.Len() == 0 {
+			close(logged)
+		}
+		return buf.Write(p)
+	}), "", 0))
+	g.DebugDetectStuckTerminate(10 * time.Millisecond)
+	g.AddNamed(
+		"stuck",
+		func() error {
+			<-cancel
+			return nil
+		},
+		func(e error) {},
+	)
+	g.Add(
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	select {
+	case <-logged:
+		mu.Lock()
+		if !strings.Contains(buf.String(), `member "stuck" has not returned`) {
+			t.Errorf("unexpected log output: %q", buf.String())
+		}
+		mu.Unlock()
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+
+	close(cancel)
+	if err := <-res; err != errTest {
+		t.Errorf("got unexpected error: %v", err)
+	}
+}
+
+// writerFunc is an io.Writer implemented by a function.
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+func TestGroup_SetPool(t *testing.T) {
+	var submitted int32
+
+	var g Group
+	g.SetPool(func(task func()) error {
+		atomic.AddInt32(&submitted, 1)
+		go task()
+		return nil
+	})
+	for i := 0; i < 2; i++ {
+		g.Add(
+			func() error {
+				return nil
+			},
+			func(e error) {},
+		)
+	}
+
+	if err := g.Run(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if n := atomic.LoadInt32(&submitted); n != 2 {
+		t.Errorf("submitted %d tasks, expected 2", n)
+	}
+}
+
+func TestGroup_SetPoolExhausted(t *testing.T) {
+	errFull := errors.New("pool full")
+	cancel := make(chan struct{})
+
+	var g Group
+	var submitted int
+	g.SetPool(func(task func()) error {
+		if submitted == 1 {
+			return errFull
+		}
+		submitted++
+		go task()
+		return nil
+	})
+	g.Add(
+		func() error {
+			<-cancel
+			return nil
+		},
+		func(e error) {
+			close(cancel)
+		},
+	)
+	var terminated bool
+	g.Add(
+		func() error {
+			t.Error("routine called, but not expected")
+			return nil
+		},
+		func(e error) {
+			terminated = true
+		},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	select {
+	case err := <-res:
+		if !errors.Is(err, ErrPoolExhausted) || !errors.Is(err, errFull) {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if !terminated {
+			t.Error("terminate not called for member which never started")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_Spawn(t *testing.T) {
+	var g Group
+	g.AddCtx(
+		func(ctx context.Context) error {
+			g.Spawn(ctx, func() error {
+				return errTest
+			})
+			<-ctx.Done()
+			return nil
+		},
+		func(e error) {},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	select {
+	case err := <-res:
+		if err != errTest {
+			t.Errorf("got unexpected error: %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_SpawnAfterMembers(t *testing.T) {
+	var g Group
+	g.AddCtx(
+		func(ctx context.Context) error {
+			g.Spawn(ctx, func() error {
+				time.Sleep(10 * time.Millisecond)
+				return errTest
+			})
+			return nil
+		},
+		func(e error) {},
+	)
+
+	if err := g.Run(); err != errTest {
+		t.Errorf("got unexpected error: %v", err)
+	}
+}
+
+type testLimiter struct {
+	waits int32
+}
+
+func (l *testLimiter) Wait(ctx context.Context) error {
+	atomic.AddInt32(&l.waits, 1)
+	return nil
+}
+
+func TestGroup_SetRateLimiter(t *testing.T) {
+	limiter := &testLimiter{}
+
+	var g Group
+	g.SetRateLimiter(limiter)
+	for i := 0; i < 2; i++ {
+		g.AddCtx(
+			func(ctx context.Context) error {
+				return RateLimiter(ctx).Wait(ctx)
+			},
+			func(e error) {},
+		)
+	}
+
+	if err := g.Run(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if n := atomic.LoadInt32(&limiter.waits); n != 2 {
+		t.Errorf("limiter waited %d times, expected 2", n)
+	}
+}
+
+func TestRateLimiter_None(t *testing.T) {
+	if l := RateLimiter(context.Background()); l != nil {
+		t.Errorf("unexpected limiter: %v", l)
+	}
+}
+
+func TestGroup_RunWithCallback(t *testing.T) {
+	cancel := make(chan struct{})
+
+	var g Group
+	g.Add(
+		func() error {
+			<-cancel
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	res := make(chan error, 2)
+	g.RunWithCallback(func(err error) {
+		res <- err
+	})
+	if !g.IsRunning() {
+		t.Error("group not running, but expected")
+	}
+
+	// The group is already running, so a second run is rejected.
+	g.RunWithCallback(func(err error) {
+		res <- err
+	})
+	if err := <-res; err != ErrRunning {
+		t.Errorf("got unexpected error: %v", err)
+	}
+
+	close(cancel)
+	select {
+	case err := <-res:
+		if err != errTest {
+			t.Errorf("got unexpected error: %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_AddActorsErr(t *testing.T) {
+	var g Group
+	err := g.AddActorsErr(
+		Actor{
+			Name:      "one",
+			Routine:   func() error { return nil },
+			Terminate: func(e error) {},
+		},
+		Actor{
+			Routine:   func() error { return nil },
+			Terminate: func(e error) {},
+		},
+	)
+	if err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if len(g.members) != 2 {
+		t.Errorf("unexpected number of members: %d", len(g.members))
+	}
+}
+
+func TestGroup_AddActorsErrInvalid(t *testing.T) {
+	valid := Actor{
+		Name:      "one",
+		Routine:   func() error { return nil },
+		Terminate: func(e error) {},
+	}
+
+	tests := []struct {
+		actor    Actor
+		expected error
+	}{
+		{Actor{Name: "two", Terminate: func(e error) {}}, ErrNilRoutine},
+		{Actor{Name: "two", Routine: func() error { return nil }}, ErrNilTerminate},
+		{valid, ErrDuplicateMember},
+	}
+	for _, test := range tests {
+		var g Group
+		err := g.AddActorsErr(valid, test.actor)
+		if !errors.Is(err, test.expected) {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if len(g.members) != 0 {
+			t.Errorf("members added, but not expected")
+		}
+	}
+}
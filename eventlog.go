@@ -0,0 +1,90 @@
+package errgroup
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// eventBuffer is the number of lifecycle events which may be waiting to be
+// written before further events are dropped.
+const eventBuffer = 1024
+
+// lifecycleEvent is a lifecycle event of a run written by RunWithEventLog.
+type lifecycleEvent struct {
+	Time   time.Time `json:"time"`
+	Event  string    `json:"event"`
+	Member string    `json:"member,omitempty"`
+	Error  string    `json:"error,omitempty"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// RunWithEventLog runs the Group like Run, writing its lifecycle events to w
+// as newline-delimited JSON while it runs, e.g. to os.Stderr for quick
+// operational visibility.
+//
+// An event is written when each member's routine starts ("started") and
+// returns ("finished"), when each member finishes terminating
+// ("terminated"), and when the run is done ("done"), before IsRunning reports
+// false. Events are buffered and written in the background so a slow writer
+// does not stall the run; if the buffer fills up, further events are
+// dropped. Errors writing to w are ignored. All events have been written
+// when RunWithEventLog returns.
+func (g *Group) RunWithEventLog(w io.Writer) error {
+	l, err := g.begin()
+	if err != nil {
+		return err
+	}
+
+	events := make(chan lifecycleEvent, eventBuffer)
+	g.mu.Lock()
+	g.events = events
+	g.mu.Unlock()
+
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		writeEvents(w, events)
+	}()
+
+	// The "done" event is sent by complete before the Group stops running.
+	err = g.complete(l)
+	close(events)
+	<-written
+	return err
+}
+
+// writeEvents writes events to w as newline-delimited JSON until the
+// channel is closed, flushing whenever no more events are waiting.
+func writeEvents(w io.Writer, events <-chan lifecycleEvent) {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for e := range events {
+		_ = enc.Encode(e)
+		if len(events) == 0 {
+			_ = bw.Flush()
+		}
+	}
+	_ = bw.Flush()
+}
+
+// emit a lifecycle event if events are being logged.
+func (g *Group) emit(e lifecycleEvent) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.send(e)
+}
+
+// send a lifecycle event if events are being logged, dropping it if the
+// buffer is full. g.mu must be held.
+func (g *Group) send(e lifecycleEvent) {
+	if g.events == nil {
+		return
+	}
+	e.Time = g.clock().Now()
+	select {
+	case g.events <- e:
+	default:
+	}
+}
@@ -0,0 +1,49 @@
+package errgroup
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestGroup_RunWithEventLog(t *testing.T) {
+	var buf bytes.Buffer
+
+	var g Group
+	g.AddNamed(
+		"test",
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	if err := g.RunWithEventLog(&buf); err != errTest {
+		t.Errorf("got unexpected error: %v", err)
+	}
+
+	var events []string
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var e lifecycleEvent
+		if err := dec.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+		if e.Event != "done" && e.Member != "test" {
+			t.Errorf("unexpected member: %q", e.Member)
+		}
+		events = append(events, e.Event)
+		if e.Event == "done" && (e.Error != errTest.Error() || e.Reason != "member error") {
+			t.Errorf("unexpected done event: %+v", e)
+		}
+	}
+	want := []string{"started", "finished", "terminated", "done"}
+	if len(events) != len(want) {
+		t.Fatalf("unexpected events: %v", events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("unexpected events: %v", events)
+		}
+	}
+}
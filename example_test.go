@@ -98,7 +98,7 @@ func ExampleGroup_Run_signals() {
 	var g Group
 
 	// Add a member which terminates on signal.
-	sig := make(chan os.Signal)
+	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGHUP)
 	g.Add(
 		func() error {
@@ -0,0 +1,75 @@
+package errgroup
+
+import (
+	"context"
+	"time"
+)
+
+// IdleTimeout sets how long the members of the Group may go without
+// reporting activity with Touch before the Group terminates, e.g. for an
+// on-demand service which should spin down when it is not used.
+//
+// The idle period starts when the Group runs and restarts whenever a member
+// calls Touch. If it elapses, all members are terminated with a nil error,
+// Run returns nil and StopReason reports ReasonIdle. The period is measured
+// with the Clock set by SetClock. A timeout of zero or less disables it,
+// which is the default.
+func (g *Group) IdleTimeout(d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.idle = d
+}
+
+// activityKey is the context key for the activity of a run.
+type activityKey struct{}
+
+// Touch reports activity for the Group running the member which was given
+// ctx, restarting the idle period set by IdleTimeout. It does nothing if
+// the Group has no idle timeout or ctx does not belong to a member.
+func Touch(ctx context.Context) {
+	if a, ok := ctx.Value(activityKey{}).(*activity); ok {
+		a.touch()
+	}
+}
+
+// activity tracks the activity reported by the members of a run.
+type activity struct {
+	touched chan struct{}
+}
+
+func newActivity() *activity {
+	return &activity{touched: make(chan struct{}, 1)}
+}
+
+// touch reports activity without blocking.
+func (a *activity) touch() {
+	select {
+	case a.touched <- struct{}{}:
+	default:
+	}
+}
+
+// watch returns a channel which is closed once no activity has been
+// reported for d, or never if stop is closed first.
+func (a *activity) watch(clk Clock, d time.Duration, stop <-chan struct{}) <-chan struct{} {
+	idle := make(chan struct{})
+	go func() {
+		timer := clk.NewTimer(d)
+		defer func() {
+			timer.Stop()
+		}()
+		for {
+			select {
+			case <-a.touched:
+				timer.Stop()
+				timer = clk.NewTimer(d)
+			case <-timer.C():
+				close(idle)
+				return
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return idle
+}
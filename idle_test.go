@@ -0,0 +1,66 @@
+package errgroup
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGroup_IdleTimeout(t *testing.T) {
+	clk := newFakeClock()
+	touch := make(chan struct{})
+	cancel := make(chan struct{})
+	var once sync.Once
+
+	var g Group
+	g.SetClock(clk)
+	g.IdleTimeout(time.Minute)
+	g.AddCtx(
+		func(ctx context.Context) error {
+			for {
+				select {
+				case <-touch:
+					Touch(ctx)
+				case <-cancel:
+					return nil
+				}
+			}
+		},
+		func(e error) {
+			once.Do(func() {
+				close(cancel)
+			})
+		},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	// Activity restarts the idle period.
+	<-clk.created
+	clk.Advance(30 * time.Second)
+	touch <- struct{}{}
+	<-clk.created
+	clk.Advance(45 * time.Second)
+	select {
+	case err := <-res:
+		t.Fatalf("run returned before going idle: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	clk.Advance(15 * time.Second)
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if r := g.StopReason(); r != ReasonIdle {
+			t.Errorf("unexpected stop reason: %v", r)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
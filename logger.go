@@ -0,0 +1,64 @@
+package errgroup
+
+import (
+	"context"
+	"log"
+)
+
+// Logger is the interface used by a Group and its members to log messages.
+// It is satisfied by *log.Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// stdLogger logs messages with the standard logger of the log package.
+type stdLogger struct{}
+
+func (stdLogger) Printf(format string, v ...interface{}) {
+	log.Printf(format, v...)
+}
+
+// memberLogger is a Logger which tags messages with the name of a member.
+type memberLogger struct {
+	name   string
+	logger Logger
+}
+
+func (l *memberLogger) Printf(format string, v ...interface{}) {
+	if l.name == "" {
+		l.logger.Printf(format, v...)
+		return
+	}
+	l.logger.Printf("%s: "+format, append([]interface{}{l.name}, v...)...)
+}
+
+// loggerKey is the context key for the Logger of a member.
+type loggerKey struct{}
+
+// MemberLogger returns a Logger from the context passed to a member added
+// with AddCtx, which tags each message with the member's name. If ctx was
+// not passed to a member, the standard logger of the log package is
+// returned.
+func MemberLogger(ctx context.Context) Logger {
+	if l, ok := ctx.Value(loggerKey{}).(Logger); ok {
+		return l
+	}
+	return stdLogger{}
+}
+
+// SetLogger sets the Logger used by the Group and by the Loggers given to
+// its members through MemberLogger. By default, the standard logger of the
+// log package is used.
+func (g *Group) SetLogger(l Logger) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.logger = l
+}
+
+// log returns the Logger used by the Group.
+func (o *options) log() Logger {
+	if o.logger != nil {
+		return o.logger
+	}
+	return stdLogger{}
+}
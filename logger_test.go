@@ -0,0 +1,36 @@
+package errgroup
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"testing"
+)
+
+func TestMemberLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	var g Group
+	g.SetLogger(log.New(&buf, "", 0))
+	g.AddNamedCtx(
+		"test",
+		func(ctx context.Context) error {
+			MemberLogger(ctx).Printf("hello %s", "world")
+			return nil
+		},
+		func(e error) {},
+	)
+
+	if err := g.Run(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if s := buf.String(); s != "test: hello world\n" {
+		t.Errorf("unexpected log output: %q", s)
+	}
+}
+
+func TestMemberLogger_NoMember(t *testing.T) {
+	if _, ok := MemberLogger(context.Background()).(stdLogger); !ok {
+		t.Error("expected the standard logger")
+	}
+}
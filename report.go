@@ -0,0 +1,118 @@
+package errgroup
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// RunReport summarizes the most recent run of a Group.
+type RunReport struct {
+	// Reason is why the run finished, as returned by StopReason.
+	Reason Reason
+
+	// Err is the error which Run returned.
+	Err error
+
+	// Errors holds the errors returned by member routines, as returned by
+	// Errors.
+	Errors []error
+
+	// CleanupErrors holds the errors which occurred while the run was
+	// shutting down, as returned by CleanupErrors.
+	CleanupErrors []error
+
+	// Duration is how long the run took.
+	Duration time.Duration
+
+	// Members holds the result of each member's routine, in the order in
+	// which the routines returned.
+	Members []MemberReport
+}
+
+// MemberReport is the result of a member's routine in a run of a Group.
+type MemberReport struct {
+	// Name is the name of the member, if it has one.
+	Name string
+
+	// Labels are the labels of the member, if it was added with
+	// AddLabeled.
+	Labels map[string]string
+
+	// Err is the error returned by the member's routine.
+	Err error
+
+	// Duration is how long the member's routine ran for.
+	Duration time.Duration
+}
+
+// Report returns a summary of the most recent run of the Group, e.g. for
+// logging as JSON once Run returns.
+//
+// If the Group is running, the report only includes the members whose
+// routines have returned so far, and its Reason, Err and Duration are not
+// yet set.
+func (g *Group) Report() RunReport {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	r := RunReport{
+		Reason:        g.reason,
+		Err:           g.err,
+		Errors:        make([]error, len(g.errs)),
+		CleanupErrors: make([]error, len(g.cleanup)),
+		Members:       make([]MemberReport, len(g.timings)),
+	}
+	copy(r.Errors, g.errs)
+	copy(r.CleanupErrors, g.cleanup)
+	copy(r.Members, g.timings)
+	if !g.running {
+		r.Duration = g.elapsed
+	}
+	return r
+}
+
+// reportJSON is the JSON encoding of a RunReport.
+type reportJSON struct {
+	Reason   string       `json:"reason"`
+	Err      string       `json:"error,omitempty"`
+	Errors   []string     `json:"errors,omitempty"`
+	Cleanup  []string     `json:"cleanup_errors,omitempty"`
+	Duration string       `json:"duration"`
+	Members  []memberJSON `json:"members"`
+}
+
+// memberJSON is the JSON encoding of a MemberReport.
+type memberJSON struct {
+	Name     string            `json:"name"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Err      string            `json:"error,omitempty"`
+	Duration string            `json:"duration"`
+}
+
+// MarshalJSON encodes the report as JSON, with errors and durations encoded
+// as strings.
+func (r RunReport) MarshalJSON() ([]byte, error) {
+	j := reportJSON{
+		Reason:   r.Reason.String(),
+		Err:      errorString(r.Err),
+		Duration: r.Duration.String(),
+		Members:  make([]memberJSON, len(r.Members)),
+	}
+	for _, err := range r.Errors {
+		j.Errors = append(j.Errors, errorString(err))
+	}
+	for _, err := range r.CleanupErrors {
+		j.Cleanup = append(j.Cleanup, errorString(err))
+	}
+	for i, m := range r.Members {
+		j.Members[i] = memberJSON{Name: m.Name, Labels: m.Labels, Err: errorString(m.Err), Duration: m.Duration.String()}
+	}
+	return json.Marshal(j)
+}
+
+// errorString returns the message of err, or an empty string if it is nil.
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
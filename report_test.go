@@ -0,0 +1,118 @@
+package errgroup
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestGroup_Report(t *testing.T) {
+	cancel := make(chan struct{})
+
+	var g Group
+	g.AddNamed(
+		"failed",
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+	g.AddNamed(
+		"terminated",
+		func() error {
+			<-cancel
+			return nil
+		},
+		func(e error) {
+			close(cancel)
+		},
+	)
+
+	if err := g.Run(); err != errTest {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+
+	r := g.Report()
+	if r.Reason != ReasonMemberError {
+		t.Errorf("unexpected reason: %v", r.Reason)
+	}
+	if r.Err != errTest {
+		t.Errorf("unexpected error: %v", r.Err)
+	}
+	if len(r.Errors) != 1 || r.Errors[0] != errTest {
+		t.Errorf("unexpected errors: %v", r.Errors)
+	}
+	if r.Duration <= 0 {
+		t.Errorf("unexpected duration: %v", r.Duration)
+	}
+	if len(r.Members) != 2 {
+		t.Fatalf("unexpected members: %v", r.Members)
+	}
+	if r.Members[0].Name != "failed" || r.Members[0].Err != errTest {
+		t.Errorf("unexpected first member: %v", r.Members[0])
+	}
+	if r.Members[1].Name != "terminated" || r.Members[1].Err != nil {
+		t.Errorf("unexpected second member: %v", r.Members[1])
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var j reportJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		t.Fatal(err)
+	}
+	if j.Reason != "member error" || j.Err != errTest.Error() {
+		t.Errorf("unexpected JSON report: %s", data)
+	}
+}
+
+func TestGroup_ReportTerminatePanic(t *testing.T) {
+	var calledTerminate bool
+	cancel := make(chan struct{})
+
+	var g Group
+	g.RecoverPanics(true)
+	g.Add(
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+	g.AddNamed(
+		"panicked",
+		func() error {
+			return nil
+		},
+		func(e error) {
+			panic("test panic")
+		},
+	)
+	g.Add(
+		func() error {
+			<-cancel
+			return nil
+		},
+		func(e error) {
+			calledTerminate = true
+			close(cancel)
+		},
+	)
+
+	if err := g.Run(); err != errTest {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if !calledTerminate {
+		t.Error("terminate not called after panic")
+	}
+
+	r := g.Report()
+	if len(r.CleanupErrors) != 1 {
+		t.Fatalf("unexpected cleanup errors: %v", r.CleanupErrors)
+	}
+	var perr *PanicError
+	if !errors.As(r.CleanupErrors[0], &perr) || perr.Name != "panicked" || len(perr.Stack) == 0 {
+		t.Errorf("unexpected cleanup error: %v", r.CleanupErrors[0])
+	}
+}
@@ -0,0 +1,124 @@
+package errgroup
+
+import (
+	"context"
+	"time"
+)
+
+// Schedule describes the windows of time in which a member added with
+// AddScheduled is active.
+type Schedule interface {
+	// Next returns the start and end of the first window which ends after
+	// t. If t is within a window, start is at or before t.
+	Next(t time.Time) (start, end time.Time)
+}
+
+// Daily returns a Schedule with a window every day between the given
+// offsets from midnight in the local time zone, e.g. Daily(9*time.Hour,
+// 17*time.Hour) for working hours. If end is not after start, the window
+// spans midnight.
+func Daily(start, end time.Duration) Schedule {
+	return daily{start: start, end: end}
+}
+
+// daily is a Schedule with a window every day.
+type daily struct {
+	start time.Duration
+	end   time.Duration
+}
+
+// Next returns the first daily window which ends after t.
+func (d daily) Next(t time.Time) (time.Time, time.Time) {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	for i := -1; ; i++ {
+		day := midnight.AddDate(0, 0, i)
+		start, end := day.Add(d.start), day.Add(d.end)
+		if d.end <= d.start {
+			end = day.AddDate(0, 0, 1).Add(d.end)
+		}
+		if end.After(t) {
+			return start, end
+		}
+	}
+}
+
+// AddScheduled adds a new named member to the Group which is only active
+// during the windows of the given Schedule, e.g. for a job which should
+// only run during the night.
+//
+// The member's routine is called when a window opens. When the window
+// closes, the member's terminate function is called with a nil error to stop
+// just that member, without terminating the Group, and the routine is
+// called again when the next window opens; the routine must therefore be
+// safe to call again after it has been terminated. Errors returned by the
+// routine while a window is open terminate the Group as usual, while those
+// returned after the member was terminated at the close of a window are
+// ignored. If the routine returns nil, it is not called again until the
+// next window.
+func (g *Group) AddScheduled(name string, window Schedule, routine func() error, terminate func(error)) {
+	g.AddNamedCtx(
+		name,
+		func(ctx context.Context) error {
+			return scheduled(ctx, g.clock(), window, routine, terminate)
+		},
+		terminate,
+	)
+}
+
+// scheduled runs routine during each window of the Schedule until ctx is
+// done.
+func scheduled(ctx context.Context, clk Clock, window Schedule, routine func() error, terminate func(error)) error {
+	// Within a run, the terminate function is called as the Group calls
+	// it, so that IdempotentTerminate applies to each window.
+	stop := terminate
+	rearm := func() bool { return ctx.Err() == nil }
+	if a, ok := ctx.Value(activationKey{}).(*activation); ok {
+		stop, rearm = a.terminate, a.rearm
+	}
+
+	for {
+		start, end := window.Next(clk.Now())
+		if !sleep(ctx, clk, start.Sub(clk.Now())) || !rearm() {
+			return nil
+		}
+
+		done := make(chan error, 1)
+		go func() {
+			done <- routine()
+		}()
+
+		closing := clk.NewTimer(end.Sub(clk.Now()))
+		select {
+		case err := <-done:
+			closing.Stop()
+			if err != nil {
+				return err
+			}
+			if !sleep(ctx, clk, end.Sub(clk.Now())) {
+				return nil
+			}
+		case <-closing.C():
+			stop(nil)
+			<-done
+		case <-ctx.Done():
+			// The Group is terminating and calls the terminate function.
+			closing.Stop()
+			return <-done
+		}
+	}
+}
+
+// sleep for d, returning false if ctx is done first.
+func sleep(ctx context.Context, clk Clock, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := clk.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,152 @@
+package errgroup
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testSchedule has windows of the given length with gaps of the same length
+// in between, starting with a gap at its epoch.
+type testSchedule struct {
+	epoch  time.Time
+	length time.Duration
+}
+
+func (s testSchedule) Next(t time.Time) (time.Time, time.Time) {
+	period := 2 * s.length
+	n := t.Sub(s.epoch) / period
+	start := s.epoch.Add(n*period + s.length)
+	return start, start.Add(s.length)
+}
+
+func TestDaily(t *testing.T) {
+	day := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(h int) time.Time {
+		return day.Add(time.Duration(h) * time.Hour)
+	}
+
+	s := Daily(9*time.Hour, 17*time.Hour)
+	if start, end := s.Next(at(12)); !start.Equal(at(9)) || !end.Equal(at(17)) {
+		t.Errorf("unexpected window: %v - %v", start, end)
+	}
+	if start, end := s.Next(at(18)); !start.Equal(at(24+9)) || !end.Equal(at(24+17)) {
+		t.Errorf("unexpected window: %v - %v", start, end)
+	}
+
+	// A window spanning midnight.
+	s = Daily(22*time.Hour, 6*time.Hour)
+	if start, end := s.Next(at(2)); !start.Equal(at(-2)) || !end.Equal(at(6)) {
+		t.Errorf("unexpected window: %v - %v", start, end)
+	}
+	if start, end := s.Next(at(12)); !start.Equal(at(22)) || !end.Equal(at(24+6)) {
+		t.Errorf("unexpected window: %v - %v", start, end)
+	}
+}
+
+func TestGroup_AddScheduled(t *testing.T) {
+	var started, terminated int32
+	cancel := make(chan struct{}, 1)
+
+	var g Group
+	g.AddScheduled(
+		"scheduled",
+		testSchedule{epoch: time.Now(), length: 10 * time.Millisecond},
+		func() error {
+			atomic.AddInt32(&started, 1)
+			<-cancel
+			return nil
+		},
+		func(e error) {
+			atomic.AddInt32(&terminated, 1)
+			select {
+			case cancel <- struct{}{}:
+			default:
+			}
+		},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	time.Sleep(55 * time.Millisecond)
+	g.Stop()
+
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if n := atomic.LoadInt32(&started); n < 2 {
+			t.Errorf("routine started %d times, expected at least 2", n)
+		}
+		if n := atomic.LoadInt32(&terminated); n < 2 {
+			t.Errorf("terminate called %d times, expected at least 2", n)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_AddScheduledIdempotentTerminate(t *testing.T) {
+	clk := newFakeClock()
+	started := make(chan struct{})
+	var calls int32
+	var mu sync.Mutex
+	var stop chan struct{}
+
+	var g Group
+	g.SetClock(clk)
+	g.IdempotentTerminate(true)
+	g.AddScheduled(
+		"scheduled",
+		testSchedule{epoch: clk.Now(), length: time.Hour},
+		func() error {
+			mu.Lock()
+			stop = make(chan struct{})
+			ch := stop
+			mu.Unlock()
+			started <- struct{}{}
+			<-ch
+			return nil
+		},
+		func(e error) {
+			atomic.AddInt32(&calls, 1)
+			mu.Lock()
+			defer mu.Unlock()
+			// Closing the channel again would panic.
+			close(stop)
+		},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	// Open the window, then close it, which terminates the routine.
+	<-clk.created
+	clk.Advance(time.Hour)
+	<-started
+	<-clk.created
+	clk.Advance(time.Hour)
+
+	// Stop the Group while waiting for the next window.
+	<-clk.created
+	g.Stop()
+
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if n := atomic.LoadInt32(&calls); n != 1 {
+			t.Errorf("terminate called %d times, expected 1", n)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
@@ -0,0 +1,55 @@
+package errgroup
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// SignalError is returned by the routine of a signal handler member when a
+// signal is received.
+type SignalError struct {
+	// Signal is the signal which was received.
+	Signal os.Signal
+}
+
+// Error returns the error message.
+func (e *SignalError) Error() string {
+	return fmt.Sprintf("errgroup: received signal: %v", e.Signal)
+}
+
+// SignalHandler returns the routine and terminate function of a member which
+// returns a *SignalError when one of the given signals is received, e.g. to
+// stop the Group on Ctrl-C.
+//
+// Without any signals, the member handles os.Interrupt, and on Unix systems
+// also syscall.SIGTERM; on other systems such as Windows only os.Interrupt
+// is handled, since it is the only signal which is reliably delivered.
+// Signals are handled from when SignalHandler is called until the terminate
+// function is called, after which the member cannot be run again.
+func SignalHandler(sigs ...os.Signal) (func() error, func(error)) {
+	if len(sigs) == 0 {
+		sigs = defaultSignals
+	}
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, sigs...)
+
+	done := make(chan struct{})
+	var once sync.Once
+	routine := func() error {
+		select {
+		case sig := <-c:
+			return &SignalError{Signal: sig}
+		case <-done:
+			return nil
+		}
+	}
+	terminate := func(error) {
+		once.Do(func() {
+			signal.Stop(c)
+			close(done)
+		})
+	}
+	return routine, terminate
+}
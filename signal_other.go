@@ -0,0 +1,11 @@
+//go:build !unix
+
+package errgroup
+
+import (
+	"os"
+)
+
+// defaultSignals are the signals handled by SignalHandler by default. Only
+// os.Interrupt is reliably delivered on systems other than Unix.
+var defaultSignals = []os.Signal{os.Interrupt}
@@ -0,0 +1,26 @@
+package errgroup
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignalHandler_Terminate(t *testing.T) {
+	routine, terminate := SignalHandler()
+
+	res := make(chan error)
+	go func() {
+		res <- routine()
+	}()
+	terminate(nil)
+	terminate(nil)
+
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
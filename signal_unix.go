@@ -0,0 +1,11 @@
+//go:build unix
+
+package errgroup
+
+import (
+	"os"
+	"syscall"
+)
+
+// defaultSignals are the signals handled by SignalHandler by default.
+var defaultSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
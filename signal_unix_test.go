@@ -0,0 +1,37 @@
+//go:build unix
+
+package errgroup
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSignalHandler(t *testing.T) {
+	routine, terminate := SignalHandler()
+
+	var g Group
+	g.Add(routine, terminate)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	g.WaitStarted()
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-res:
+		var serr *SignalError
+		if !errors.As(err, &serr) || serr.Signal != syscall.SIGTERM {
+			t.Errorf("got unexpected error: %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
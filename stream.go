@@ -0,0 +1,181 @@
+package errgroup
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// RunStream runs the Group like Run, and additionally runs each actor
+// received from actors as a member of the Group as it arrives, e.g. to
+// dispatch streamed work items.
+//
+// Closing actors means no more members will arrive; the run then finishes
+// once the streamed members and the members of the Group have returned as
+// usual. An error from any streamed member terminates the Group, including
+// the other streamed members, and no more actors are received from actors.
+// An actor without a routine or terminate function fails the run with
+// ErrNilRoutine or ErrNilTerminate. Streamed members are run in the same way
+// as the members of the Group, so options such as RecoverPanics, Use and
+// SetAttribution apply to them, and on the pool set by SetPool, if any. They
+// are not added to the Group, so they do not take part in later runs, but
+// they count towards the Progress of the run and are reported to the
+// function registered with OnTerminated once terminated. Streamed members
+// which return cleanly are terminated along with the Group. If ctx is done
+// before the run finishes, the run is stopped as with Stop.
+func (g *Group) RunStream(ctx context.Context, actors <-chan Actor) error {
+	l, err := g.begin()
+	if err != nil {
+		return err
+	}
+
+	r := &relay{g: g, actors: actors, term: make(chan error, 1)}
+	dispatcher := &member{relay: true, ctxRoutine: r.dispatch, terminate: r.terminate}
+	l.members = append(l.members[:len(l.members):len(l.members)], dispatcher)
+
+	// Only this run is stopped if ctx is done, even if the watch outlives
+	// it briefly.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			g.mu.Lock()
+			g.halt(l.stop)
+			g.mu.Unlock()
+		case <-done:
+		}
+	}()
+	return g.complete(l)
+}
+
+// relay runs the actors received by RunStream as members of the Group.
+type relay struct {
+	g      *Group
+	actors <-chan Actor
+
+	// term receives the error which terminates the Group while the
+	// actors are being dispatched.
+	term chan error
+
+	// mu guards started and returned, which are shared by dispatch and
+	// terminate.
+	mu       sync.Mutex
+	started  []streamed
+	returned bool
+}
+
+// streamed is a member run by a relay, along with its state in the run.
+type streamed struct {
+	m  *member
+	st *state
+}
+
+// dispatch runs the actors received from actors until the channel is closed
+// and all of them have returned, one of them fails, or an error is received
+// from term when the Group terminates.
+//
+// Each actor is run as a member in the same way as the members of the
+// Group, with a context derived from ctx.
+func (r *relay) dispatch(ctx context.Context) error {
+	g := r.g
+	errs := make(chan error)
+	running := 0
+
+	// stop terminates the actors which have started, returning err once
+	// they have all returned.
+	stop := func(err error) error {
+		for _, s := range r.started {
+			s.st.cancel(err)
+			g.terminate(s.m, s.st, err)
+		}
+		for ; running > 0; running-- {
+			if e := <-errs; e != nil {
+				g.record(e)
+			}
+		}
+		for _, s := range r.started {
+			g.terminated(s.m, err)
+		}
+		return err
+	}
+
+	actors := r.actors
+	for actors != nil || running > 0 {
+		select {
+		case a, ok := <-actors:
+			if !ok {
+				actors = nil
+				continue
+			}
+			switch {
+			case a.Routine == nil:
+				return stop(fmt.Errorf("%w: actor %q", ErrNilRoutine, a.Name))
+			case a.Terminate == nil:
+				return stop(fmt.Errorf("%w: actor %q", ErrNilTerminate, a.Name))
+			}
+			m := &member{name: a.Name, routine: a.Routine, terminate: a.Terminate}
+			mctx, st := g.memberContext(ctx, m)
+			task := func() {
+				err := g.perform(mctx, m, st)
+				g.exit(m, st)
+				errs <- err
+			}
+			g.mu.Lock()
+			g.total++
+			g.mu.Unlock()
+			if err := g.submit(task); err != nil {
+				g.mu.Lock()
+				g.total--
+				g.mu.Unlock()
+				return stop(err)
+			}
+			running++
+			r.mu.Lock()
+			r.started = append(r.started, streamed{m, st})
+			r.mu.Unlock()
+		case err := <-errs:
+			running--
+			if err != nil {
+				return stop(err)
+			}
+		case err := <-r.term:
+			stop(err)
+			return nil
+		}
+	}
+
+	// All actors have returned cleanly, so they are terminated along with
+	// the Group by terminate, unless the Group is already terminating.
+	r.mu.Lock()
+	r.returned = true
+	r.mu.Unlock()
+	select {
+	case err := <-r.term:
+		stop(err)
+	default:
+	}
+	return nil
+}
+
+// terminate the actors run by dispatch. While they are being dispatched,
+// dispatch terminates them; once all of them have returned cleanly, they
+// are terminated here.
+func (r *relay) terminate(err error) {
+	r.mu.Lock()
+	if !r.returned {
+		select {
+		case r.term <- err:
+		default:
+		}
+		r.mu.Unlock()
+		return
+	}
+	r.mu.Unlock()
+
+	for _, s := range r.started {
+		s.st.cancel(err)
+		r.g.terminate(s.m, s.st, err)
+		r.g.terminated(s.m, err)
+	}
+}
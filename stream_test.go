@@ -0,0 +1,312 @@
+package errgroup
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGroup_RunStream(t *testing.T) {
+	actors := make(chan Actor)
+	results := make(chan int, 3)
+
+	var g Group
+	res := make(chan error)
+	go func() {
+		res <- g.RunStream(context.Background(), actors)
+	}()
+
+	for i := 0; i < 3; i++ {
+		i := i
+		actors <- Actor{
+			Routine: func() error {
+				results <- i
+				return nil
+			},
+			Terminate: func(e error) {},
+		}
+	}
+	close(actors)
+
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if len(results) != 3 {
+			t.Errorf("%d streamed routines ran, expected 3", len(results))
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_RunStreamError(t *testing.T) {
+	actors := make(chan Actor)
+	cancel := make(chan struct{})
+
+	var g Group
+	g.Add(
+		func() error {
+			<-cancel
+			return nil
+		},
+		func(e error) {
+			close(cancel)
+		},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.RunStream(context.Background(), actors)
+	}()
+
+	streamed := make(chan struct{})
+	actors <- Actor{
+		Routine: func() error {
+			<-streamed
+			return nil
+		},
+		Terminate: func(e error) {
+			if e != errTest {
+				t.Errorf("terminate got unexpected error: %v", e)
+			}
+			close(streamed)
+		},
+	}
+	actors <- Actor{
+		Routine: func() error {
+			return errTest
+		},
+		Terminate: func(e error) {},
+	}
+
+	select {
+	case err := <-res:
+		if err != errTest {
+			t.Errorf("got unexpected error: %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+	if len(g.members) != 1 {
+		t.Error("streamed members added to the group")
+	}
+}
+
+func TestGroup_RunStreamContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	actors := make(chan Actor)
+
+	var g Group
+	res := make(chan error)
+	go func() {
+		res <- g.RunStream(ctx, actors)
+	}()
+
+	g.WaitStarted()
+	cancel()
+
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if r := g.StopReason(); r != ReasonStopped {
+			t.Errorf("unexpected stop reason: %v", r)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_RunStreamNilRoutine(t *testing.T) {
+	actors := make(chan Actor, 1)
+	actors <- Actor{Name: "test", Terminate: func(e error) {}}
+
+	var g Group
+	if err := g.RunStream(context.Background(), actors); !errors.Is(err, ErrNilRoutine) {
+		t.Errorf("got unexpected error: %v", err)
+	}
+}
+
+func TestGroup_RunStreamRecoverPanics(t *testing.T) {
+	actors := make(chan Actor, 1)
+	actors <- Actor{
+		Name: "streamed",
+		Routine: func() error {
+			panic("test panic")
+		},
+		Terminate: func(e error) {},
+	}
+	close(actors)
+
+	var g Group
+	g.RecoverPanics(true)
+	g.SetAttribution(true)
+
+	err := g.RunStream(context.Background(), actors)
+	var perr *PanicError
+	if !errors.As(err, &perr) || perr.Name != "streamed" {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	var merr *MemberError
+	if !errors.As(err, &merr) || merr.Name != "streamed" {
+		t.Errorf("error not attributed: %v", err)
+	}
+	if _, ok := merr.Err.(*MemberError); ok {
+		t.Errorf("error attributed twice: %v", err)
+	}
+}
+
+func TestGroup_RunStreamIdempotentTerminate(t *testing.T) {
+	actors := make(chan Actor)
+	cancel := make(chan struct{})
+
+	var g Group
+	g.IdempotentTerminate(true)
+
+	res := make(chan error)
+	go func() {
+		res <- g.RunStream(context.Background(), actors)
+	}()
+
+	actors <- Actor{
+		Name: "a",
+		Routine: func() error {
+			<-cancel
+			return nil
+		},
+		Terminate: func(e error) {
+			close(cancel)
+		},
+	}
+	actors <- Actor{
+		Name: "b",
+		Routine: func() error {
+			return errTest
+		},
+		Terminate: func(e error) {},
+	}
+
+	select {
+	case err := <-res:
+		if err != errTest {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		counts := map[string]int{}
+		for _, name := range g.TerminateOrder() {
+			counts[name]++
+		}
+		if counts["a"] != 1 || counts["b"] != 1 {
+			t.Errorf("unexpected terminate order: %q", g.TerminateOrder())
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestGroup_RunStreamReportCompletion(t *testing.T) {
+	var g Group
+	g.ReportCompletion(true)
+
+	// The dispatcher of the streamed members is not a member itself.
+	actors := make(chan Actor)
+	close(actors)
+	if err := g.RunStream(context.Background(), actors); err != ErrNoMembers {
+		t.Errorf("got unexpected error: %v", err)
+	}
+
+	actors = make(chan Actor, 1)
+	actors <- Actor{
+		Routine: func() error {
+			return nil
+		},
+		Terminate: func(e error) {},
+	}
+	close(actors)
+	if err := g.RunStream(context.Background(), actors); err != ErrAllCompleted {
+		t.Errorf("got unexpected error: %v", err)
+	}
+}
+
+func TestGroup_RunStreamTerminated(t *testing.T) {
+	var terminated []string
+
+	var g Group
+	g.OnTerminated(func(name string, err error) {
+		terminated = append(terminated, name)
+	})
+
+	actors := make(chan Actor, 1)
+	actors <- Actor{
+		Name: "test",
+		Routine: func() error {
+			return nil
+		},
+		Terminate: func(e error) {},
+	}
+	close(actors)
+
+	if err := g.RunStream(context.Background(), actors); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if len(terminated) != 1 || terminated[0] != "test" {
+		t.Errorf("unexpected terminated members: %q", terminated)
+	}
+	if order := g.TerminateOrder(); len(order) != 1 || order[0] != "test" {
+		t.Errorf("unexpected terminate order: %q", order)
+	}
+}
+
+func TestGroup_RunStreamProgress(t *testing.T) {
+	cancel := make(chan struct{})
+
+	var g Group
+	g.Add(
+		func() error {
+			<-cancel
+			return nil
+		},
+		func(e error) {
+			close(cancel)
+		},
+	)
+
+	actors := make(chan Actor)
+	res := make(chan error)
+	go func() {
+		res <- g.RunStream(context.Background(), actors)
+	}()
+
+	returned := make(chan struct{})
+	actors <- Actor{
+		Routine: func() error {
+			close(returned)
+			return nil
+		},
+		Terminate: func(e error) {},
+	}
+	<-returned
+
+	// The streamed member counts towards the progress, but the dispatcher
+	// does not.
+	deadline := time.Now().Add(100 * time.Millisecond)
+	for g.Progress() != 0.5 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if p := g.Progress(); p != 0.5 {
+		t.Errorf("unexpected progress: %v", p)
+	}
+	g.Stop()
+
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
@@ -0,0 +1,76 @@
+package errgroup
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNilRoutine is reported by Validate and Run for a member without a
+// routine.
+var ErrNilRoutine = errors.New("errgroup: member has no routine")
+
+// ErrNilTerminate is reported by Validate and Run for a member without a
+// terminate function.
+var ErrNilTerminate = errors.New("errgroup: member has no terminate function")
+
+// ErrDuplicateMember is reported for a member whose name is already used by
+// another member of the Group.
+var ErrDuplicateMember = errors.New("errgroup: duplicate member name")
+
+// ErrInvalidConfig is reported by Validate and Run for a Group whose options
+// or member settings are invalid or conflict with each other.
+var ErrInvalidConfig = errors.New("errgroup: invalid configuration")
+
+// Validate checks the configuration of the Group without running it,
+// returning the first problem found. It performs the same checks Run does
+// before starting any member routines.
+//
+// Member names must be unique so that members can be referenced by name
+// unambiguously; members without a name are not checked.
+func (g *Group) Validate() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.validate()
+}
+
+// validate checks the configuration of the Group. g.mu must be held.
+func (g *Group) validate() error {
+	if g.quorum > 0 && g.firstNil {
+		return fmt.Errorf("%w: StopAfterN and StopOnFirstNil are both set", ErrInvalidConfig)
+	}
+	if g.quorum > len(g.members) {
+		return fmt.Errorf("%w: StopAfterN(%d) exceeds the number of members (%d)", ErrInvalidConfig, g.quorum, len(g.members))
+	}
+
+	names := make(map[string]bool, len(g.members))
+	for i, m := range g.members {
+		switch {
+		case m.name != "" && names[m.name]:
+			return fmt.Errorf("%w: %s", ErrDuplicateMember, m.label(i))
+		case m.routine == nil && m.ctxRoutine == nil && !g.skipNil:
+			return fmt.Errorf("%w: %s", ErrNilRoutine, m.label(i))
+		case m.terminate == nil:
+			return fmt.Errorf("%w: %s", ErrNilTerminate, m.label(i))
+		case m.health != nil && m.interval <= 0:
+			return fmt.Errorf("%w: %s: health check interval must be positive", ErrInvalidConfig, m.label(i))
+		case m.health != nil && m.failures <= 0:
+			return fmt.Errorf("%w: %s: health check failures must be positive", ErrInvalidConfig, m.label(i))
+		case m.hasTimeout && m.timeout <= 0:
+			return fmt.Errorf("%w: %s: timeout must be positive", ErrInvalidConfig, m.label(i))
+		case m.hasWorkers && m.workers <= 0:
+			return fmt.Errorf("%w: %s: worker count must be positive", ErrInvalidConfig, m.label(i))
+		case g.memory > 0 && m.cost > g.memory:
+			return fmt.Errorf("%w: %s: memory cost exceeds the memory budget", ErrInvalidConfig, m.label(i))
+		}
+		names[m.name] = true
+	}
+	return nil
+}
+
+// label identifies the member at index i of a Group in error messages.
+func (m *member) label(i int) string {
+	if m.name != "" {
+		return fmt.Sprintf("member %q", m.name)
+	}
+	return fmt.Sprintf("member %d", i)
+}
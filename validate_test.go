@@ -0,0 +1,218 @@
+package errgroup
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestGroup_Validate(t *testing.T) {
+	var g Group
+	if err := g.Validate(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+
+	g.Add(
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+	if err := g.Validate(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+}
+
+func TestGroup_ValidateNilRoutine(t *testing.T) {
+	var g Group
+	g.AddNamed("test", nil, func(e error) {})
+
+	if err := g.Validate(); !errors.Is(err, ErrNilRoutine) {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if err := g.Run(); !errors.Is(err, ErrNilRoutine) {
+		t.Errorf("got unexpected error from run: %v", err)
+	}
+	if g.IsRunning() {
+		t.Error("group running, but not expected")
+	}
+}
+
+func TestGroup_SkipNilRoutines(t *testing.T) {
+	var buf bytes.Buffer
+	var called bool
+
+	var g Group
+	g.SetLogger(log.New(&buf, "", 0))
+	g.SkipNilRoutines(true)
+	g.AddNamed("skipped", nil, func(e error) {})
+	g.Add(
+		func() error {
+			called = true
+			return nil
+		},
+		func(e error) {},
+	)
+
+	if err := g.Validate(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if err := g.Run(); err != nil {
+		t.Errorf("got unexpected error from run: %v", err)
+	}
+	if !called {
+		t.Error("routine not called, but expected")
+	}
+	if s := buf.String(); s != "errgroup: skipping member \"skipped\": no routine\n" {
+		t.Errorf("unexpected log output: %q", s)
+	}
+}
+
+func TestGroup_ValidateNilTerminate(t *testing.T) {
+	var g Group
+	g.Add(
+		func() error {
+			return nil
+		},
+		nil,
+	)
+
+	if err := g.Validate(); !errors.Is(err, ErrNilTerminate) {
+		t.Errorf("got unexpected error: %v", err)
+	}
+}
+
+func TestGroup_ValidateConflictingOptions(t *testing.T) {
+	var g Group
+	g.Add(
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+	g.StopAfterN(1)
+	g.StopOnFirstNil(true)
+
+	if err := g.Validate(); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("got unexpected error: %v", err)
+	}
+}
+
+func TestGroup_ValidateUnreachableQuorum(t *testing.T) {
+	var g Group
+	g.Add(
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+	g.StopAfterN(2)
+
+	if err := g.Validate(); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("got unexpected error: %v", err)
+	}
+}
+
+func TestGroup_ValidateHealthCheck(t *testing.T) {
+	var g Group
+	g.AddWatched(
+		"test",
+		func() error {
+			return nil
+		},
+		func() error {
+			return nil
+		},
+		func(e error) {},
+		0,
+		1,
+	)
+
+	if err := g.Validate(); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("got unexpected error: %v", err)
+	}
+
+	g.members[0].interval = time.Millisecond
+	if err := g.Validate(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+}
+
+func TestGroup_ValidateTimeout(t *testing.T) {
+	var g Group
+	g.AddWithTimeout(
+		func() error {
+			return nil
+		},
+		func(e error) {},
+		0,
+	)
+
+	if err := g.Validate(); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("got unexpected error: %v", err)
+	}
+
+	g.members[0].timeout = time.Millisecond
+	if err := g.Validate(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+}
+
+func TestGroup_ValidateWorkers(t *testing.T) {
+	var g Group
+	g.AddWorkers(
+		"test",
+		0,
+		func() error {
+			return nil
+		},
+		func(e error) {},
+	)
+
+	if err := g.Validate(); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("got unexpected error: %v", err)
+	}
+
+	g.members[0].workers = 1
+	if err := g.Validate(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+}
+
+func TestGroup_ValidateDuplicateMember(t *testing.T) {
+	var g Group
+	for i := 0; i < 2; i++ {
+		g.AddNamed(
+			"test",
+			func() error {
+				return nil
+			},
+			func(e error) {},
+		)
+	}
+
+	if err := g.Validate(); !errors.Is(err, ErrDuplicateMember) {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if err := g.Run(); !errors.Is(err, ErrDuplicateMember) {
+		t.Errorf("got unexpected error from run: %v", err)
+	}
+}
+
+func TestGroup_ValidateUnnamedMembers(t *testing.T) {
+	var g Group
+	for i := 0; i < 2; i++ {
+		g.Add(
+			func() error {
+				return nil
+			},
+			func(e error) {},
+		)
+	}
+
+	if err := g.Validate(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package errgroup
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileChangedError is returned by the routine of a file watch actor when
+// one of its watched paths changes.
+type FileChangedError struct {
+	// Path is the watched path which changed.
+	Path string
+}
+
+// Error returns the error message.
+func (e *FileChangedError) Error() string {
+	return fmt.Sprintf("errgroup: file changed: %s", e.Path)
+}
+
+// Watcher reports changes to a set of watched paths. It allows a file
+// notification library such as fsnotify to be used with WatchActor without
+// the package depending on it.
+type Watcher interface {
+	// Changes returns a channel on which the path of each change is sent.
+	// The channel is closed once the Watcher is closed.
+	Changes() <-chan string
+
+	// Close stops watching for changes.
+	Close() error
+}
+
+// FileWatchActor returns the routine and terminate function of a member
+// which watches the given paths and returns a *FileChangedError when one of
+// them changes, e.g. to stop the Group when its configuration changes.
+//
+// Changes are detected by polling the modification time and size of each
+// path every second, so for a directory only the addition or removal of
+// entries is detected. An error is returned if a path cannot be read. The
+// paths are watched from when FileWatchActor is called until the terminate
+// function is called, after which the member cannot be run again; to run
+// the Group again, e.g. to reload the configuration, create a new actor and
+// swap it in with Replace. Use WatchActor with NewFileWatcher to poll at
+// another interval or with a fake Clock, or with another Watcher for other
+// ways of watching.
+func FileWatchActor(paths ...string) (func() error, func(error), error) {
+	w, err := NewFileWatcher(nil, time.Second, paths...)
+	if err != nil {
+		return nil, nil, err
+	}
+	routine, terminate := WatchActor(w)
+	return routine, terminate, nil
+}
+
+// WatchActor returns the routine and terminate function of a member which
+// returns a *FileChangedError when the Watcher reports a change. The
+// terminate function closes the Watcher, after which the routine returns
+// nil if no change was reported and the member cannot be run again.
+func WatchActor(w Watcher) (func() error, func(error)) {
+	var once sync.Once
+	routine := func() error {
+		path, ok := <-w.Changes()
+		if !ok {
+			return nil
+		}
+		return &FileChangedError{Path: path}
+	}
+	terminate := func(error) {
+		once.Do(func() {
+			w.Close()
+		})
+	}
+	return routine, terminate
+}
+
+// fileStat is the state of a watched path used to detect changes.
+type fileStat struct {
+	modTime time.Time
+	size    int64
+}
+
+// pollWatcher is a Watcher which polls its paths for changes.
+type pollWatcher struct {
+	changes chan string
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewFileWatcher returns a Watcher which checks the given paths for changes
+// by polling their modification time and size at the given interval, as
+// measured by clk. A nil clk uses the real time. An error is returned if a
+// path cannot be read.
+func NewFileWatcher(clk Clock, interval time.Duration, paths ...string) (Watcher, error) {
+	if clk == nil {
+		clk = realClock{}
+	}
+	stats := make(map[string]fileStat, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		stats[path] = fileStat{modTime: info.ModTime(), size: info.Size()}
+	}
+
+	w := &pollWatcher{changes: make(chan string), done: make(chan struct{})}
+	go w.poll(clk, interval, paths, stats)
+	return w, nil
+}
+
+// poll the paths for changes until the Watcher is closed. A path which can
+// no longer be read is reported as changed.
+func (w *pollWatcher) poll(clk Clock, interval time.Duration, paths []string, stats map[string]fileStat) {
+	defer close(w.changes)
+	for {
+		timer := clk.NewTimer(interval)
+		select {
+		case <-timer.C():
+		case <-w.done:
+			timer.Stop()
+			return
+		}
+		for _, path := range paths {
+			info, err := os.Stat(path)
+			if err == nil && (fileStat{modTime: info.ModTime(), size: info.Size()}) == stats[path] {
+				continue
+			}
+			if err == nil {
+				stats[path] = fileStat{modTime: info.ModTime(), size: info.Size()}
+			}
+			select {
+			case w.changes <- path:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+// Changes returns the channel on which changed paths are sent.
+func (w *pollWatcher) Changes() <-chan string {
+	return w.changes
+}
+
+// Close stops polling for changes.
+func (w *pollWatcher) Close() error {
+	w.once.Do(func() {
+		close(w.done)
+	})
+	return nil
+}
@@ -0,0 +1,113 @@
+package errgroup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileWatchActor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte("a"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	routine, terminate, err := FileWatchActor(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var g Group
+	g.Add(routine, terminate)
+	g.Add(
+		func() error {
+			return errTest
+		},
+		func(e error) {},
+	)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	select {
+	case err := <-res:
+		if err != errTest {
+			t.Errorf("got unexpected error: %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestFileWatchActor_Missing(t *testing.T) {
+	_, _, err := FileWatchActor(filepath.Join(t.TempDir(), "missing"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("got unexpected error: %v", err)
+	}
+}
+
+func TestNewFileWatcher(t *testing.T) {
+	clk := newFakeClock()
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte("a"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewFileWatcher(clk, time.Second, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	routine, terminate := WatchActor(w)
+
+	var g Group
+	g.Add(routine, terminate)
+
+	res := make(chan error)
+	go func() {
+		res <- g.Run()
+	}()
+
+	<-clk.created
+	if err := os.WriteFile(path, []byte("changed"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	clk.Advance(time.Second)
+
+	select {
+	case err := <-res:
+		var changed *FileChangedError
+		if !errors.As(err, &changed) || changed.Path != path {
+			t.Errorf("got unexpected error: %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}
+
+func TestWatchActor_Terminate(t *testing.T) {
+	w, err := NewFileWatcher(nil, time.Hour, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	routine, terminate := WatchActor(w)
+
+	res := make(chan error)
+	go func() {
+		res <- routine()
+	}()
+	terminate(nil)
+	terminate(nil)
+
+	select {
+	case err := <-res:
+		if err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("test case timeout")
+	}
+}